@@ -0,0 +1,197 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+
+	"github.com/CaiJinKen/fillstruct/fill"
+)
+
+// compact removes the keys of lit whose value is provably the zero value
+// of the field, recursively. Only constants, nil and literals made of them
+// are considered, anything else may have side effects and is kept. It
+// reports whether lit is left equal to the zero value of its type.
+func (h *handler) compact(lit *ast.CompositeLit) bool {
+	typ := h.pkg.TypesInfo.TypeOf(lit)
+	if typ == nil {
+		return false
+	}
+
+	switch t := typ.Underlying().(type) {
+	case *types.Struct:
+		if _, keyed := fill.KeyedElts(lit); !keyed {
+			// fields of positional literals cannot be removed
+			return h.allZero(lit.Elts, t)
+		}
+		elts := lit.Elts[:0]
+		prevEnd := lit.Lbrace
+		for i, e := range lit.Elts {
+			kv := e.(*ast.KeyValueExpr)
+			if !h.isZero(kv.Value, fieldType(t, kv.Key)) {
+				elts = append(elts, kv)
+				prevEnd = kv.End()
+				continue
+			}
+			next := lit.Rbrace
+			if i+1 < len(lit.Elts) {
+				next = lit.Elts[i+1].Pos()
+			}
+			h.dropComments(h.f, kv, next)
+			h.removeLines(prevEnd, kv, next)
+		}
+		lit.Elts = elts
+		return len(lit.Elts) == 0
+	case *types.Array:
+		// removing elements would shift the others
+		return h.allZero(lit.Elts, t)
+	default:
+		// slice and map literals are not nil even when empty,
+		// their elements are still compacted
+		h.allZero(lit.Elts, t)
+		return false
+	}
+}
+
+// allZero reports whether all elements of a literal of type t are zero
+// values, compacting every one of them
+func (h *handler) allZero(elts []ast.Expr, t types.Type) bool {
+	zero := true
+	for i, e := range elts {
+		var typ types.Type
+		switch t := t.(type) {
+		case *types.Struct:
+			if i < t.NumFields() {
+				typ = t.Field(i).Type()
+			}
+		case *types.Array:
+			typ = t.Elem()
+		case *types.Slice:
+			typ = t.Elem()
+		case *types.Map:
+			typ = t.Elem()
+		}
+		if kv, ok := e.(*ast.KeyValueExpr); ok {
+			e = kv.Value
+		}
+		if !h.isZero(e, typ) {
+			zero = false
+		}
+	}
+	return zero
+}
+
+// fieldType returns the type of the field of t named by key, nil if
+// there is none
+func fieldType(t *types.Struct, key ast.Expr) types.Type {
+	if id, ok := key.(*ast.Ident); ok {
+		for i := 0; i < t.NumFields(); i++ {
+			if t.Field(i).Name() == id.Name {
+				return t.Field(i).Type()
+			}
+		}
+	}
+	return nil
+}
+
+// isZero reports whether expr is provably the zero value of typ, the type
+// of the field or element it is assigned to. A pointer to a literal that
+// compacts to nothing, e.g. &A{}, counts as zero too: such values are what
+// filling a pointer field produces. Only nil is the zero value of an
+// interface, 0 stored in it is not.
+func (h *handler) isZero(expr ast.Expr, typ types.Type) bool {
+	if typ == nil {
+		return false
+	}
+	tv, ok := h.pkg.TypesInfo.Types[expr]
+	if types.IsInterface(typ) {
+		if ok && tv.IsNil() {
+			return true
+		}
+		if ok && tv.Type != nil && !types.IsInterface(tv.Type) {
+			// literals stored in the interface are still compacted
+			h.isZero(expr, tv.Type)
+		}
+		return false
+	}
+
+	switch {
+	case ok && tv.Value != nil:
+		return fill.IsZeroConst(tv.Value)
+	case ok && tv.IsNil():
+		return true
+	}
+
+	switch e := expr.(type) {
+	case *ast.ParenExpr:
+		return h.isZero(e.X, typ)
+	case *ast.UnaryExpr:
+		if lit, ok := e.X.(*ast.CompositeLit); ok && e.Op == token.AND {
+			return h.compact(lit)
+		}
+	case *ast.CompositeLit:
+		return h.compact(e)
+	}
+	return false
+}
+
+// dropComments removes the comments of af inside node and the line
+// comment following it, up to next, so they are not left behind when
+// node is removed
+func (h *handler) dropComments(af *ast.File, node ast.Node, next token.Pos) {
+	line := h.pkg.Fset.Position(node.End()).Line
+	comments := af.Comments[:0]
+	for _, c := range af.Comments {
+		inside := c.Pos() >= node.Pos() && c.End() <= node.End()
+		trailing := c.Pos() >= node.End() && c.End() <= next && h.pkg.Fset.Position(c.Pos()).Line == line
+		if inside || trailing {
+			continue
+		}
+		comments = append(comments, c)
+	}
+	af.Comments = comments
+}
+
+// lineRange is a range of lines of the file, both ends included
+type lineRange struct {
+	start, end int
+}
+
+// removeLines records the lines of a removed node to be merged into the
+// previous line, so that the printer does not leave blank lines behind.
+// Only the lines the node has on its own, between the end of the previous
+// element and the start of the next one, are removed.
+func (h *handler) removeLines(prevEnd token.Pos, node ast.Node, next token.Pos) {
+	r := lineRange{
+		start: h.pkg.Fset.Position(node.Pos()).Line,
+		end:   h.pkg.Fset.Position(node.End()).Line,
+	}
+	if h.pkg.Fset.Position(prevEnd).Line >= r.start || h.pkg.Fset.Position(next).Line <= r.end {
+		return
+	}
+	// lines of nested nodes removed earlier are covered by r
+	merges := h.merges[:0]
+	for _, m := range h.merges {
+		if m.start < r.start || m.end > r.end {
+			merges = append(merges, m)
+		}
+	}
+	h.merges = append(merges, r)
+}
+
+// mergeLines merges the recorded line ranges of af into their previous
+// lines. It changes the line of every position after them, so it is only
+// done once the literal has been handled.
+func (h *handler) mergeLines(af *ast.File) {
+	sort.Slice(h.merges, func(i, j int) bool {
+		return h.merges[i].start > h.merges[j].start
+	})
+	file := h.pkg.Fset.File(af.Pos())
+	for _, m := range h.merges {
+		for i := m.start; i <= m.end; i++ {
+			file.MergeLine(m.start - 1)
+		}
+	}
+	h.merges = nil
+}
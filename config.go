@@ -0,0 +1,389 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/CaiJinKen/fillstruct/fill"
+	"gopkg.in/yaml.v3"
+)
+
+// configName is the name of the configuration file, looked up from the
+// directory of the filled file up to the module root
+const configName = ".fillstruct.yaml"
+
+// config is the content of a configuration file
+type config struct {
+	settings `yaml:",inline"`
+
+	// overrides by directory relative to the configuration file,
+	// dir/... applying to the directories below it too
+	Packages map[string]settings `yaml:"packages,omitempty"`
+}
+
+// settings are the defaults a configuration file sets. Every setting is
+// optional, flags given on the command line take precedence.
+type settings struct {
+	Mode          string `yaml:"mode,omitempty"` // fill, compact, keyed or positional
+	Tags          string `yaml:"tags,omitempty"`
+	Force         *bool  `yaml:"force,omitempty"`
+	Partial       *bool  `yaml:"partial,omitempty"`
+	PruneUnknown  *bool  `yaml:"prune-unknown,omitempty"`
+	RenameUnknown *bool  `yaml:"rename-unknown,omitempty"`
+	EnumFirst     *bool  `yaml:"enum-first,omitempty"`
+	TagDefaults   *bool  `yaml:"tag-defaults,omitempty"`
+	CtorDefaults  *bool  `yaml:"constructor-defaults,omitempty"`
+	UseScope      *bool  `yaml:"use-scope,omitempty"`
+
+	ExportedOnly *bool                   `yaml:"exported-only,omitempty"`
+	Include      []string                `yaml:"include,omitempty"`
+	Exclude      []string                `yaml:"exclude,omitempty"`
+	WithTag      []string                `yaml:"with-tag,omitempty"`
+	SkipIgnored  []string                `yaml:"skip-ignored,omitempty"`
+	Types        map[string]typeSettings `yaml:"types,omitempty"` // field filters by qualified type name
+
+	Depth     *int           `yaml:"depth,omitempty"`
+	PathDepth map[string]int `yaml:"path-depth,omitempty"` // levels of fields to fill below a field path
+
+	Values   string                `yaml:"values,omitempty"`
+	Seed     *int64                `yaml:"seed,omitempty"`
+	Examples []fill.ExampleSetting `yaml:"examples,omitempty"` // tried before the built-in ones
+
+	Implementations   string            `yaml:"implementations,omitempty"`
+	UseImplementation map[string]string `yaml:"use-implementation,omitempty"` // implementation by interface, qualified names
+
+	Presets map[string]string `yaml:"presets,omitempty"` // as given to -preset, by type
+	Format  fill.Format       `yaml:"format,omitempty"`
+}
+
+// typeSettings are the field filters of a type, their paths are
+// relative to the type
+type typeSettings struct {
+	ExportedOnly bool     `yaml:"exported-only,omitempty"`
+	Include      []string `yaml:"include,omitempty"`
+	Exclude      []string `yaml:"exclude,omitempty"`
+	WithTag      []string `yaml:"with-tag,omitempty"`
+	SkipIgnored  []string `yaml:"skip-ignored,omitempty"`
+}
+
+// findConfig reads the configuration file nearest to dir, looking up to
+// the module root. It returns a nil config if there is none.
+func findConfig(dir string) (*config, string, error) {
+	for {
+		path := filepath.Join(dir, configName)
+		data, err := os.ReadFile(path)
+		if err == nil {
+			cfg, err := parseConfig(data)
+			if err != nil {
+				return nil, "", fmt.Errorf("%s: %v", path, err)
+			}
+			return cfg, path, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, "", err
+		}
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return nil, "", nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, "", nil
+		}
+		dir = parent
+	}
+}
+
+func parseConfig(data []byte) (*config, error) {
+	cfg := &config{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// forDir returns the settings applying to dir, relative to the
+// configuration file. Overrides for more specific directories win.
+func (c *config) forDir(dir string) settings {
+	dir = filepath.ToSlash(dir)
+	keys := make([]string, 0, len(c.Packages))
+	for key := range c.Packages {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return len(keys[i]) < len(keys[j])
+	})
+
+	s := c.settings
+	for _, key := range keys {
+		if matchDir(strings.TrimPrefix(key, "./"), dir) {
+			s = s.merge(c.Packages[key])
+		}
+	}
+	return s
+}
+
+// matchDir reports whether pattern, a directory or dir/..., matches dir
+func matchDir(pattern, dir string) bool {
+	if pattern == "..." {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(pattern, "/..."); ok {
+		return dir == prefix || strings.HasPrefix(dir, prefix+"/")
+	}
+	return strings.TrimSuffix(pattern, "/") == dir
+}
+
+// merge returns s with the settings given by o replacing its own
+func (s settings) merge(o settings) settings {
+	if o.Mode != "" {
+		s.Mode = o.Mode
+	}
+	if o.Tags != "" {
+		s.Tags = o.Tags
+	}
+	if o.Values != "" {
+		s.Values = o.Values
+	}
+	if o.Implementations != "" {
+		s.Implementations = o.Implementations
+	}
+	if o.Examples != nil {
+		s.Examples = append(append([]fill.ExampleSetting{}, o.Examples...), s.Examples...)
+	}
+	for _, b := range []struct{ dst, src **bool }{
+		{&s.Force, &o.Force},
+		{&s.Partial, &o.Partial},
+		{&s.PruneUnknown, &o.PruneUnknown},
+		{&s.RenameUnknown, &o.RenameUnknown},
+		{&s.EnumFirst, &o.EnumFirst},
+		{&s.TagDefaults, &o.TagDefaults},
+		{&s.CtorDefaults, &o.CtorDefaults},
+		{&s.UseScope, &o.UseScope},
+		{&s.ExportedOnly, &o.ExportedOnly},
+	} {
+		if *b.src != nil {
+			*b.dst = *b.src
+		}
+	}
+	for _, l := range []struct{ dst, src *[]string }{
+		{&s.Include, &o.Include},
+		{&s.Exclude, &o.Exclude},
+		{&s.WithTag, &o.WithTag},
+		{&s.SkipIgnored, &o.SkipIgnored},
+		{&s.Format.SkipPrefixes, &o.Format.SkipPrefixes},
+	} {
+		if *l.src != nil {
+			*l.dst = *l.src
+		}
+	}
+	if o.Depth != nil {
+		s.Depth = o.Depth
+	}
+	if o.Seed != nil {
+		s.Seed = o.Seed
+	}
+	if o.Format.Float != "" {
+		s.Format.Float = o.Format.Float
+	}
+	if o.Format.FuncBody != "" {
+		s.Format.FuncBody = o.Format.FuncBody
+	}
+	s.Types = mergeMaps(s.Types, o.Types)
+	s.PathDepth = mergeMaps(s.PathDepth, o.PathDepth)
+	s.Presets = mergeMaps(s.Presets, o.Presets)
+	s.UseImplementation = mergeMaps(s.UseImplementation, o.UseImplementation)
+	return s
+}
+
+func mergeMaps[V any](dst, src map[string]V) map[string]V {
+	if len(src) == 0 {
+		return dst
+	}
+	m := make(map[string]V, len(dst)+len(src))
+	for k, v := range dst {
+		m[k] = v
+	}
+	for k, v := range src {
+		m[k] = v
+	}
+	return m
+}
+
+// applyFlags sets the flags not given on the command line from s
+func (s settings) applyFlags() error {
+	given := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+	set := func(name, value string) error {
+		if given[name] {
+			return nil
+		}
+		return flag.Set(name, value)
+	}
+
+	switch s.Mode {
+	case "", "fill":
+	case "compact", "keyed", "positional":
+		if !given["compact"] && !given["keyed"] && !given["positional"] {
+			if err := flag.Set(s.Mode, "true"); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("invalid mode %q, want fill, compact, keyed or positional", s.Mode)
+	}
+
+	for name, value := range map[string]string{
+		"tags":            s.Tags,
+		"values":          s.Values,
+		"implementations": s.Implementations,
+	} {
+		if value == "" {
+			continue
+		}
+		if err := set(name, value); err != nil {
+			return err
+		}
+	}
+	for name, b := range map[string]*bool{
+		"force":                s.Force,
+		"partial":              s.Partial,
+		"prune-unknown":        s.PruneUnknown,
+		"rename-unknown":       s.RenameUnknown,
+		"enum-first":           s.EnumFirst,
+		"tag-defaults":         s.TagDefaults,
+		"constructor-defaults": s.CtorDefaults,
+		"use-scope":            s.UseScope,
+		"exported-only":        s.ExportedOnly,
+	} {
+		if b == nil {
+			continue
+		}
+		if err := set(name, strconv.FormatBool(*b)); err != nil {
+			return err
+		}
+	}
+	if s.Depth != nil {
+		if err := set("depth", strconv.Itoa(*s.Depth)); err != nil {
+			return err
+		}
+	}
+	if s.Seed != nil {
+		if err := set("seed", strconv.FormatInt(*s.Seed, 10)); err != nil {
+			return err
+		}
+	}
+	for name, list := range map[string][]string{
+		"include":      s.Include,
+		"exclude":      s.Exclude,
+		"with-tag":     s.WithTag,
+		"skip-ignored": s.SkipIgnored,
+	} {
+		for _, v := range list {
+			if err := set(name, v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// configure applies the configuration file of the filled file, if any
+func (h *handler) configure() error {
+	dir := filepath.Dir(h.filepath)
+	cfg, path, err := findConfig(dir)
+	if err != nil || cfg == nil {
+		return err
+	}
+	rel, err := filepath.Rel(filepath.Dir(path), dir)
+	if err != nil {
+		return err
+	}
+	h.configPath = path
+	h.settings = cfg.forDir(rel)
+	if err := h.settings.applyFlags(); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return nil
+}
+
+// printConfig writes the effective configuration for the file, that of
+// its configuration file completed by the flags and the defaults
+func (h *handler) printConfig(w io.Writer) error {
+	path, err := absPath(*filename)
+	if err != nil {
+		return err
+	}
+	h.filepath = path
+	if err := h.configure(); err != nil {
+		return err
+	}
+	opts, err := h.fillOptions()
+	if err != nil {
+		return err
+	}
+
+	mode := "fill"
+	switch {
+	case *compactLit:
+		mode = "compact"
+	case *keyed:
+		mode = "keyed"
+	case *positional:
+		mode = "positional"
+	}
+	s := settings{
+		Mode:              mode,
+		Tags:              *tags,
+		Values:            *values,
+		Seed:              seed,
+		Examples:          h.settings.Examples,
+		Implementations:   *implMode,
+		UseImplementation: h.settings.UseImplementation,
+		Force:             force,
+		Partial:           partial,
+		PruneUnknown:      pruneUnknown,
+		RenameUnknown:     renameUnknown,
+		EnumFirst:         enumFirst,
+		TagDefaults:       tagDefaults,
+		CtorDefaults:      ctorDefaults,
+		UseScope:          useScope,
+		ExportedOnly:      exportedOnly,
+		Include:           include,
+		Exclude:           exclude,
+		WithTag:           withTag,
+		SkipIgnored:       skipIgnored,
+		Types:             h.settings.Types,
+		Depth:             depth,
+		PathDepth:         h.settings.PathDepth,
+		Presets:           make(map[string]string, len(opts.Presets)),
+		Format:            opts.Format.OrDefault(),
+	}
+	for typ, p := range opts.Presets {
+		s.Presets[typ] = p.String()
+	}
+
+	if h.configPath != "" {
+		fmt.Fprintf(w, "# %s\n", h.configPath)
+	} else {
+		fmt.Fprintf(w, "# no %s found, defaults and flags only\n", configName)
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(s); err != nil {
+		return err
+	}
+	return enc.Close()
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"os"
+)
+
+// exit codes of fillstruct, documented in README.md
+const (
+	exitFailure     = 1 // invalid usage or the file could not be loaded
+	exitNotFound    = 2 // no struct literal found at the assigned line
+	exitTypeError   = 3 // the package or the literal has type errors
+	exitUnsupported = 4 // the literal is not of a struct type
+	exitWriteFailed = 5 // the result could not be formatted or written
+)
+
+// exitError is an error carrying the exit code of the process
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+
+func (e *exitError) Unwrap() error { return e.err }
+
+// fatal logs err and exits with the code carried by err, if any
+func fatal(err error) {
+	log.Println(err)
+	var e *exitError
+	if errors.As(err, &e) {
+		os.Exit(e.code)
+	}
+	os.Exit(exitFailure)
+}
@@ -0,0 +1,280 @@
+package fill
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// constructorDefaults returns the values the constructor of named gives
+// to its fields, by field name, as written at the filled literal. Only the
+// values that can be evaluated statically are returned.
+func (f *Filler) constructorDefaults(named *types.Named) map[string]string {
+	if values, ok := f.ctorValues[named]; ok {
+		return values
+	}
+	var values map[string]string
+	if fn := findConstructor(named); fn != nil {
+		values = f.constructorValues(named, fn)
+	}
+	if f.ctorValues == nil {
+		f.ctorValues = make(map[*types.Named]map[string]string)
+	}
+	f.ctorValues[named] = values
+	return values
+}
+
+// findConstructor returns the function of the package of named that takes
+// no parameter and returns a value of type named or a pointer to one. It
+// is looked up as DefaultT, NewT, then any Default or New function.
+func findConstructor(named *types.Named) *types.Func {
+	obj := named.Obj()
+	if obj.Pkg() == nil || named.TypeArgs().Len() > 0 {
+		return nil
+	}
+	scope := obj.Pkg().Scope()
+	names := []string{"Default" + obj.Name(), "New" + obj.Name()}
+	var others []string
+	for _, name := range scope.Names() {
+		if strings.HasPrefix(name, "Default") || strings.HasPrefix(name, "New") {
+			others = append(others, name)
+		}
+	}
+	sort.SliceStable(others, func(i, j int) bool {
+		return strings.HasPrefix(others[i], "Default") && !strings.HasPrefix(others[j], "Default")
+	})
+
+	for _, name := range append(names, others...) {
+		fn, ok := scope.Lookup(name).(*types.Func)
+		if !ok {
+			continue
+		}
+		sig := fn.Type().(*types.Signature)
+		if sig.Params().Len() != 0 || sig.Results().Len() != 1 {
+			continue
+		}
+		res := sig.Results().At(0).Type()
+		if p, ok := res.(*types.Pointer); ok {
+			res = p.Elem()
+		}
+		if types.Identical(res, named) {
+			return fn
+		}
+	}
+	return nil
+}
+
+// constructorValues returns the field values of the literal fn returns,
+// if its body is a single return T{...} or return &T{...}
+func (f *Filler) constructorValues(named *types.Named, fn *types.Func) map[string]string {
+	decl, file, fset := f.constructorDecl(fn)
+	if decl == nil || decl.Body == nil || len(decl.Body.List) != 1 {
+		return nil
+	}
+	ret, ok := decl.Body.List[0].(*ast.ReturnStmt)
+	if !ok || len(ret.Results) != 1 {
+		return nil
+	}
+	x := ret.Results[0]
+	if u, ok := x.(*ast.UnaryExpr); ok && u.Op == token.AND {
+		x = u.X
+	}
+	lit, ok := x.(*ast.CompositeLit)
+	if !ok || len(lit.Elts) == 0 {
+		return nil
+	}
+
+	st := named.Underlying().(*types.Struct)
+	imports := make(map[string]string) // import name -> import path
+	for path, name := range ImportNames(file, fn.Pkg()) {
+		imports[name] = path
+	}
+	r := &requalifier{f: f, pkg: fn.Pkg(), imports: imports}
+	values := make(map[string]string)
+	for i, e := range lit.Elts {
+		name := ""
+		if kv, ok := e.(*ast.KeyValueExpr); ok {
+			key, ok := kv.Key.(*ast.Ident)
+			if !ok {
+				return nil
+			}
+			name, e = key.Name, kv.Value
+		} else if i < st.NumFields() {
+			name = st.Field(i).Name()
+		}
+		if v, ok := r.rewrite(fset, e); ok && name != "" {
+			values[name] = v
+		}
+	}
+	return values
+}
+
+// constructorDecl returns the declaration of fn and the file holding it,
+// parsing the file if fn is not declared in the filled package
+func (f *Filler) constructorDecl(fn *types.Func) (*ast.FuncDecl, *ast.File, *token.FileSet) {
+	if fn.Pkg() == f.pkg {
+		for _, file := range f.syntax {
+			for _, d := range file.Decls {
+				if decl, ok := d.(*ast.FuncDecl); ok && decl.Name.Pos() == fn.Pos() {
+					return decl, file, f.fset
+				}
+			}
+		}
+		return nil, nil, nil
+	}
+
+	if f.fset == nil {
+		return nil, nil, nil
+	}
+	filename := f.fset.Position(fn.Pos()).Filename
+	if filename == "" {
+		return nil, nil, nil
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, nil, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, nil, nil
+	}
+	for _, d := range file.Decls {
+		if decl, ok := d.(*ast.FuncDecl); ok && decl.Recv == nil && decl.Name.Name == fn.Name() {
+			return decl, file, fset
+		}
+	}
+	return nil, nil, nil
+}
+
+// requalifier rewrites expressions of a constructor to be written at the
+// filled literal. Only constants, types and literals made of them are
+// accepted, anything else may have side effects or differ at run time.
+type requalifier struct {
+	f       *Filler
+	pkg     *types.Package    // package of the constructor
+	imports map[string]string // import name -> import path, of the constructor's file
+}
+
+// rewrite returns the source of x as written at the filled literal
+func (r *requalifier) rewrite(fset *token.FileSet, x ast.Expr) (string, bool) {
+	// work on a copy, x may belong to the filled file
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, x); err != nil {
+		return "", false
+	}
+	x, err := parser.ParseExpr(buf.String())
+	if err != nil || !r.expr(x) {
+		return "", false
+	}
+	buf.Reset()
+	if err := format.Node(&buf, token.NewFileSet(), x); err != nil {
+		return "", false
+	}
+	return buf.String(), true
+}
+
+func (r *requalifier) expr(x ast.Expr) bool {
+	switch x := x.(type) {
+	case *ast.BasicLit:
+		return true
+	case *ast.Ident:
+		return r.ident(x)
+	case *ast.SelectorExpr:
+		return r.selector(x)
+	case *ast.ParenExpr:
+		return r.expr(x.X)
+	case *ast.UnaryExpr:
+		return x.Op != token.ARROW && r.expr(x.X)
+	case *ast.BinaryExpr:
+		return r.expr(x.X) && r.expr(x.Y)
+	case *ast.StarExpr:
+		return r.expr(x.X)
+	case *ast.ArrayType:
+		if _, ok := x.Len.(*ast.Ellipsis); !ok && x.Len != nil && !r.expr(x.Len) {
+			return false
+		}
+		return r.expr(x.Elt)
+	case *ast.MapType:
+		return r.expr(x.Key) && r.expr(x.Value)
+	case *ast.CompositeLit:
+		if x.Type != nil && !r.expr(x.Type) {
+			return false
+		}
+		_, isMap := x.Type.(*ast.MapType)
+		for _, e := range x.Elts {
+			if kv, ok := e.(*ast.KeyValueExpr); ok {
+				// keys of struct literals are field names
+				if _, isField := kv.Key.(*ast.Ident); (isMap || !isField) && !r.expr(kv.Key) {
+					return false
+				}
+				e = kv.Value
+			}
+			if !r.expr(e) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// ident qualifies id, a constant or a type of the constructor's package
+func (r *requalifier) ident(id *ast.Ident) bool {
+	obj := r.pkg.Scope().Lookup(id.Name)
+	if obj == nil {
+		obj = types.Universe.Lookup(id.Name)
+		switch obj.(type) {
+		case *types.Const, *types.TypeName, *types.Nil:
+			return true
+		}
+		return false
+	}
+	switch obj.(type) {
+	case *types.Const, *types.TypeName:
+	default:
+		return false
+	}
+	if r.pkg == r.f.pkg {
+		return true
+	}
+	name, ok := r.f.importNames[r.pkg.Path()]
+	if !ok || name == "." || !obj.Exported() {
+		return false
+	}
+	id.Name = name + "." + id.Name
+	return true
+}
+
+// selector renames the package of sel, a constant or a type of a package
+// imported by the constructor's file, to its name in the filled file
+func (r *requalifier) selector(sel *ast.SelectorExpr) bool {
+	id, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	path, ok := r.imports[id.Name]
+	if !ok {
+		return false
+	}
+	var obj types.Object
+	for _, imp := range r.pkg.Imports() {
+		if imp.Path() == path {
+			obj = imp.Scope().Lookup(sel.Sel.Name)
+			break
+		}
+	}
+	switch obj.(type) {
+	case *types.Const, *types.TypeName:
+	default:
+		return false
+	}
+	name, ok := r.f.importNames[path]
+	if !ok || name == "." || path == r.f.pkg.Path() {
+		return false
+	}
+	id.Name = name
+	return true
+}
@@ -0,0 +1,152 @@
+package fill
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/types"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// ways to make up the values of the fields, set by Options.Values
+const (
+	ValuesZero    = "zero"
+	ValuesExample = "example"
+	ValuesRandom  = "random"
+)
+
+// ExampleRule gives the example value of the fields whose name matches
+type ExampleRule struct {
+	field *regexp.Regexp // matched against the last words of the field name in snake case
+	kind  string         // string, int, float, bool or a fully qualified type, empty for any
+	value string         // expression, packages referred to by their name
+}
+
+// ExampleSetting is an example rule as written in the configuration file
+type ExampleSetting struct {
+	Field string `yaml:"field"`
+	Type  string `yaml:"type,omitempty"`
+	Value string `yaml:"value"`
+}
+
+// DefaultExamples are the built-in example rules, the last ones giving
+// the value of any field of their type
+var DefaultExamples = []ExampleSetting{
+	{Field: "e?mail", Type: "string", Value: `"user@example.com"`},
+	{Field: "ur[li]|link|website|endpoint", Type: "string", Value: `"https://example.com"`},
+	{Field: "host", Type: "string", Value: `"example.com"`},
+	{Field: "addr", Type: "string", Value: `"localhost:8080"`},
+	{Field: "ip", Type: "string", Value: `"192.0.2.1"`},
+	{Field: "phone", Type: "string", Value: `"+1 555 0100"`},
+	{Field: "user(name)?|login", Type: "string", Value: `"jdoe"`},
+	{Field: "first_?name", Type: "string", Value: `"Jane"`},
+	{Field: "last_?name", Type: "string", Value: `"Doe"`},
+	{Field: "name", Type: "string", Value: `"Jane Doe"`},
+	{Field: "title|summary|description|desc", Type: "string", Value: `"Example"`},
+	{Field: "country", Type: "string", Value: `"US"`},
+	{Field: "currency", Type: "string", Value: `"USD"`},
+	{Field: "lang|language|locale", Type: "string", Value: `"en"`},
+	{Field: "password|secret|token", Type: "string", Value: `"s3cr3t"`},
+	{Field: "id", Type: "string", Value: `"id-1"`},
+	{Field: "port", Type: "int", Value: "8080"},
+	{Field: "age", Type: "int", Value: "30"},
+	{Field: "year", Type: "int", Value: "2024"},
+	{Field: "count|total|size|limit|len", Type: "int", Value: "10"},
+	{Field: "id", Type: "int", Value: "1"},
+	{Field: "price|amount|cost", Type: "float", Value: "9.99"},
+	{Field: "ratio|rate|percent", Type: "float", Value: "0.5"},
+	{Field: "lat(itude)?", Type: "float", Value: "48.8566"},
+	{Field: "lng|lon(gitude)?", Type: "float", Value: "2.3522"},
+	{Field: "timeout|interval|ttl|delay|period", Type: "time.Duration", Value: "30 * time.Second"},
+	{Field: "", Type: "time.Time", Value: "time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)"},
+	{Field: "", Type: "time.Duration", Value: "time.Second"},
+	{Field: "", Type: "string", Value: `"example"`},
+	{Field: "", Type: "int", Value: "1"},
+	{Field: "", Type: "float", Value: "1.5"},
+	{Field: "", Type: "bool", Value: "true"},
+}
+
+// NewExampleRules compiles settings into rules, tried in order
+func NewExampleRules(settings []ExampleSetting) ([]ExampleRule, error) {
+	rules := make([]ExampleRule, 0, len(settings))
+	for _, s := range settings {
+		re, err := regexp.Compile("(?i)^(?:.*_)?(?:" + s.Field + ")s?$")
+		if s.Field == "" {
+			re, err = regexp.Compile("")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid example field %q: %v", s.Field, err)
+		}
+		if _, err := parser.ParseExpr(s.Value); err != nil {
+			return nil, fmt.Errorf("invalid example value %q: %v", s.Value, err)
+		}
+		rules = append(rules, ExampleRule{field: re, kind: s.Type, value: s.Value})
+	}
+	return rules, nil
+}
+
+// example returns the example value of the field described by info, false
+// if no rule gives one. Elements of slices and maps take the value of
+// their field, named basic types with constants take one of them.
+func (f *Filler) example(info litInfo) (ast.Expr, bool) {
+	typ := info.typ
+	if info.name != nil {
+		typ = info.name
+	}
+	if named, ok := typ.(*types.Named); ok && named.Obj().Pkg() != nil {
+		if _, ok := named.Underlying().(*types.Basic); ok && f.enumConst(named) != nil {
+			// declared constants are better examples
+			return nil, false
+		}
+	}
+	kind, name := exampleKind(typ), snakeCase(info.hint)
+	for _, r := range f.opts.Examples {
+		if r.kind != "" && r.kind != kind || !r.field.MatchString(name) {
+			continue
+		}
+		if x := f.presetExpr(r.value); x != nil {
+			return x, true
+		}
+	}
+	return nil, false
+}
+
+// exampleKind returns the kind of typ rules are matched against
+func exampleKind(typ types.Type) string {
+	switch key := presetKey(typ); key {
+	case "time.Time", "time.Duration":
+		return key
+	}
+	if b, ok := typ.Underlying().(*types.Basic); ok {
+		switch info := b.Info(); {
+		case info&types.IsBoolean != 0:
+			return "bool"
+		case info&types.IsString != 0:
+			return "string"
+		case info&types.IsInteger != 0:
+			return "int"
+		case info&types.IsFloat != 0:
+			return "float"
+		}
+	}
+	return presetKey(typ)
+}
+
+// snakeCase returns name in lower snake case, e.g. user_id for UserID
+func snakeCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || unicode.IsUpper(prev) && nextLower {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,823 @@
+package fill
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"math/rand"
+	"sort"
+	"strconv"
+)
+
+// litInfo contains the information about
+// a literal to fill with zero values.
+type litInfo struct {
+	typ       types.Type        // the base type of the literal
+	name      *types.Named      // name of the type or nil, e.g. for an anonymous struct type
+	hideType  bool              // flag to hide the element type inside an array, slice or map literal
+	isPointer bool              // true if the literal is of a pointer type
+	existing  *ast.CompositeLit // literal written by the user, nil if none
+	scope     fieldScope        // where the literal sits in the filled tree
+
+	field  *types.Var // field the value is filled for, nil for elements
+	hint   string     // name of the field the value or the element is filled for
+	tag    string     // tag of field
+	parent types.Type // type of the struct holding field
+}
+
+// Options controls how a literal is filled
+type Options struct {
+	PruneUnknown  bool // remove keys that are not fields of the literal's type
+	RenameUnknown bool // rename unknown keys to an obviously similar missing field
+
+	Filter      *FieldFilter            // fields to fill, nil for all
+	TypeFilters map[string]*FieldFilter // fields to fill by qualified type name
+
+	EnumFirst    bool // use the first declared constant of a named basic type rather than the zero-valued one
+	TagDefaults  bool // use the default values of the field tags
+	CtorDefaults bool // use the values set by the constructors of the types
+	UseScope     bool // use the identifiers in scope assignable to the fields
+
+	Values   string        // how to make up values: ValuesZero, ValuesExample or ValuesRandom
+	Examples []ExampleRule // rules giving the example values
+	Seed     int64         // seed of the random values
+
+	Implementations   string            // how to fill interface fields: empty for nil, ImplementationsFill or ImplementationsList
+	UseImplementation map[string]string // implementation to use by interface, qualified names
+
+	Depth     int            // levels of fields to fill, 0 for all
+	PathDepth map[string]int // levels of fields to fill below a field path
+
+	Presets map[string]Preset // how to fill well-known types, by fully qualified type
+	Format  Format            // how to write values
+}
+
+// Format controls how values are written
+type Format struct {
+	Float        string   `yaml:"float,omitempty"`         // zero value of floats, 0.0 by default
+	FuncBody     string   `yaml:"func-body,omitempty"`     // body of func literals: panic, zero to return zero values, or a statement
+	SkipPrefixes []string `yaml:"skip-prefixes,omitempty"` // prefixes of the fields never filled, XXX_ by default
+}
+
+// DefaultFormat is the format used for the settings left empty
+var DefaultFormat = Format{
+	Float:        "0.0",
+	FuncBody:     `panic("not implemented")`,
+	SkipPrefixes: []string{"XXX_"},
+}
+
+// OrDefault returns f with the settings left empty taken from DefaultFormat
+func (f Format) OrDefault() Format {
+	if f.Float == "" {
+		f.Float = DefaultFormat.Float
+	}
+	if f.FuncBody == "" {
+		f.FuncBody = DefaultFormat.FuncBody
+	}
+	if f.SkipPrefixes == nil {
+		f.SkipPrefixes = DefaultFormat.SkipPrefixes
+	}
+	return f
+}
+
+// UnknownField is a key written by the user that is not a field
+// of the literal's type, e.g. after the field was renamed or removed
+type UnknownField struct {
+	KV      *ast.KeyValueExpr
+	Name    string     // name of the key
+	Pos     token.Pos  // position of the key before filling
+	Type    types.Type // type of the literal
+	Similar string     // name of a missing field similar to the key, if any
+	Renamed bool       // the key was renamed to Similar
+	Pruned  bool       // the key was removed
+}
+
+// CycleCut is a pointer field left nil because filling it
+// would have entered a type already being filled
+type CycleCut struct {
+	Path string     // path of the field, with the element steps, e.g. Items[].Next
+	Type types.Type // type pointed to
+}
+
+// Filler fills the struct literals of a package
+type Filler struct {
+	pkg         *types.Package
+	pos         token.Pos
+	lines       int
+	importNames map[string]string // import path -> import name
+	opts        Options
+	unknown     []UnknownField
+	cycles      []CycleCut
+	badDefaults []BadDefault
+
+	// for the constructors of the types, with CtorDefaults
+	fset       *token.FileSet
+	syntax     []*ast.File // files of the filled package
+	ctorValues map[*types.Named]map[string]string
+
+	// identifiers in scope at the filled literal, with UseScope
+	scope     *types.Scope
+	scopePos  token.Pos
+	scopeObjs []types.Object
+
+	rng *rand.Rand // source of the random values
+
+	ifaceFields []InterfaceField // for ImplementationsList
+}
+
+// New returns a Filler of literals in pkg, importNames giving the names
+// the file imports packages under, by import path, as ImportNames does
+func New(pkg *types.Package, importNames map[string]string, opts Options) *Filler {
+	opts.Format = opts.Format.OrDefault()
+	return &Filler{
+		pkg:         pkg,
+		pos:         -1,
+		importNames: importNames,
+		opts:        opts,
+		rng:         rand.New(rand.NewSource(opts.Seed)),
+	}
+}
+
+// SetSyntax gives the files of the package, where the constructors of the
+// types are looked up with CtorDefaults. The positions of the filled
+// values are then added to fset as a file of their own, so that the layout
+// of the printed literal does not depend on the files loaded in fset.
+func (f *Filler) SetSyntax(fset *token.FileSet, files []*ast.File) {
+	f.fset, f.syntax = fset, files
+}
+
+// Fill completes lit, a struct literal of type typ, keeping the values
+// written in it. It returns nil if typ is not a struct type.
+func (f *Filler) Fill(lit *ast.CompositeLit, typ types.Type) ast.Expr {
+	var info litInfo
+	var ok bool
+	info.name, _ = typ.(*types.Named)
+	if info.typ, ok = typ.Underlying().(*types.Struct); !ok {
+		return nil
+	}
+	info.existing = lit
+	info.scope = fieldScope{filter: f.opts.Filter, limit: f.opts.Depth, depths: f.opts.PathDepth}
+	if f.fset == nil {
+		return f.zero(info, make([]types.Type, 0, 8))
+	}
+
+	// the made up positions count up from the base of a file added once
+	// they are all known, on a single line like a literal written by hand
+	base := f.fset.Base()
+	f.pos = token.Pos(base)
+	x := f.zero(info, make([]types.Type, 0, 8))
+	f.fset.AddFile("", base, int(f.pos)-base+1)
+	return x
+}
+
+// Unknown returns the keys of the filled literals that are not fields
+func (f *Filler) Unknown() []UnknownField { return f.unknown }
+
+// Cycles returns the pointer fields left nil to cut a cycle
+func (f *Filler) Cycles() []CycleCut { return f.cycles }
+
+// BadDefaults returns the default values of field tags that could not be used
+func (f *Filler) BadDefaults() []BadDefault { return f.badDefaults }
+
+// InterfaceFields returns the interface fields and their implementations,
+// with ImplementationsList
+func (f *Filler) InterfaceFields() []InterfaceField { return f.ifaceFields }
+
+// KeyedElts returns the elements of a keyed struct literal by field name.
+// It reports false if lit has an element that is not keyed by a field name.
+func KeyedElts(lit *ast.CompositeLit) (map[string]*ast.KeyValueExpr, bool) {
+	elts := make(map[string]*ast.KeyValueExpr, len(lit.Elts))
+	for _, e := range lit.Elts {
+		kv, ok := e.(*ast.KeyValueExpr)
+		if !ok {
+			return nil, false
+		}
+		key, ok := kv.Key.(*ast.Ident)
+		if !ok {
+			return nil, false
+		}
+		elts[key.Name] = kv
+	}
+	return elts, true
+}
+
+// nestedLit returns the literal written by the user as the value of a
+// field or an element of type typ: a struct literal, either T{...} or
+// &T{...}, the latter written {...} in an element, or an array, slice or
+// map literal whose elements may be struct literals.
+func nestedLit(value ast.Expr, typ types.Type) (*ast.CompositeLit, bool) {
+	if p, ok := typ.Underlying().(*types.Pointer); ok {
+		if _, ok := p.Elem().Underlying().(*types.Struct); !ok {
+			return nil, false
+		}
+		if u, ok := value.(*ast.UnaryExpr); ok && u.Op == token.AND {
+			value = u.X
+		} else if lit, ok := value.(*ast.CompositeLit); !ok || lit.Type != nil {
+			return nil, false
+		}
+		typ = p.Elem()
+	}
+	switch typ.Underlying().(type) {
+	case *types.Struct, *types.Array, *types.Slice, *types.Map:
+	default:
+		return nil, false
+	}
+	lit, ok := value.(*ast.CompositeLit)
+	return lit, ok
+}
+
+func (f *Filler) zero(info litInfo, visited []types.Type) ast.Expr {
+	if info.existing == nil && info.field != nil && len(providers) > 0 {
+		if x, ok := f.provide(info); ok {
+			return x
+		}
+	}
+	if info.existing == nil && info.field != nil && f.opts.TagDefaults {
+		if x, ok := f.tagDefault(info); ok {
+			return x
+		}
+	}
+	if info.existing == nil && info.field != nil && f.opts.UseScope {
+		if x, ok := f.scopeValue(info); ok {
+			return x
+		}
+	}
+	if info.existing == nil && f.opts.Values != ValuesZero {
+		// presets given by the user win over made-up values
+		if p, ok := f.lookupPreset(info.typ); ok && p.given {
+			return f.preset(p, info)
+		}
+	}
+	if info.existing == nil && f.opts.Values == ValuesExample {
+		if x, ok := f.example(info); ok {
+			return x
+		}
+	}
+	if info.existing == nil && f.opts.Values == ValuesRandom {
+		if x, ok := f.random(info); ok {
+			return x
+		}
+	}
+	if info.existing == nil {
+		if p, ok := f.lookupPreset(info.typ); ok {
+			return f.preset(p, info)
+		}
+	}
+
+	if info.existing == nil && info.scope.beyond() {
+		switch info.typ.(type) {
+		case *types.Chan, *types.Map, *types.Slice, *types.Signature, *types.Pointer:
+			return &ast.Ident{Name: "nil", NamePos: f.pos}
+		}
+	}
+
+	switch t := info.typ.(type) {
+	case *types.Basic:
+		return f.basicZero(t)
+	case *types.Chan:
+		valTypeName, ok := TypeString(f.pkg, f.importNames, t.Elem())
+		if !ok {
+			return nil
+		}
+
+		var dir ast.ChanDir
+		switch t.Dir() {
+		case types.SendRecv:
+			dir = ast.SEND | ast.RECV
+		case types.SendOnly:
+			dir = ast.SEND
+		case types.RecvOnly:
+			dir = ast.RECV
+		}
+
+		return &ast.CallExpr{
+			Fun: &ast.Ident{
+				NamePos: f.pos,
+				Name:    "make",
+			},
+			Lparen: f.pos,
+			Args: []ast.Expr{
+				&ast.ChanType{
+					Dir:   dir,
+					Value: ast.NewIdent(valTypeName),
+				},
+			},
+			Rparen: f.pos,
+		}
+	case *types.Interface:
+		return &ast.Ident{Name: "nil", NamePos: f.pos}
+	case *types.Map:
+		if info.existing != nil {
+			return f.completeElems(info, visited, t.Elem(), "[key]")
+		}
+		keyTypeName, ok := TypeString(f.pkg, f.importNames, t.Key())
+		if !ok {
+			return nil
+		}
+		valTypeName, ok := TypeString(f.pkg, f.importNames, t.Elem())
+		if !ok {
+			return nil
+		}
+		lit := &ast.CompositeLit{
+			Lbrace: f.pos,
+			Type: &ast.MapType{
+				Map:   f.pos,
+				Key:   ast.NewIdent(keyTypeName),
+				Value: ast.NewIdent(valTypeName),
+			},
+		}
+		f.pos++
+		lit.Elts = []ast.Expr{
+			&ast.KeyValueExpr{
+				Key:   f.zero(litInfo{typ: t.Key(), name: info.name, hideType: true, scope: info.scope.elem("{key}"), hint: info.hint}, visited),
+				Colon: f.pos,
+				Value: f.zero(litInfo{typ: t.Elem(), name: info.name, hideType: true, scope: info.scope.elem("[key]"), hint: info.hint}, visited),
+			},
+		}
+		f.pos++
+		lit.Rbrace = f.pos
+		f.lines += 2
+		return lit
+	case *types.Signature:
+		return f.funcStub(t)
+	case *types.Slice:
+		return f.fillSequence(info, visited, t, nil)
+
+	case *types.Array:
+		return f.fillSequence(info, visited, t, &ast.BasicLit{Value: strconv.FormatInt(t.Len(), 10)})
+
+	case *types.Named:
+		if _, ok := t.Underlying().(*types.Interface); ok && (f.opts.Implementations != "" || isOneof(info.tag)) {
+			if x := f.implementationOf(t, info, visited); x != nil {
+				return x
+			}
+		}
+		if _, ok := t.Underlying().(*types.Basic); ok {
+			if c := f.enumConst(t); c != nil {
+				return c
+			}
+		}
+		if _, ok := t.Underlying().(*types.Struct); ok {
+			info.name = t
+		}
+		info.typ = t.Underlying()
+		return f.zero(info, visited)
+
+	case *types.Pointer:
+		if _, ok := t.Elem().Underlying().(*types.Struct); ok {
+			if info.existing == nil && onStack(visited, t.Elem()) {
+				// allocating another one would only restart the cycle
+				f.cycles = append(f.cycles, CycleCut{Path: info.scope.shown, Type: t.Elem()})
+				return &ast.Ident{Name: "nil", NamePos: f.pos}
+			}
+			info.typ = t.Elem()
+			info.isPointer = true
+			return f.zero(info, visited)
+		}
+		return &ast.Ident{Name: "nil", NamePos: f.pos}
+
+	case *types.Struct:
+		if info.existing != nil && info.scope.beyond() {
+			f.fixExprPos(info.existing)
+			return info.existing
+		}
+
+		var existing map[string]*ast.KeyValueExpr
+		if info.existing != nil {
+			var ok bool
+			if existing, ok = KeyedElts(info.existing); !ok {
+				// positional literals are left as they are
+				f.fixExprPos(info.existing)
+				return info.existing
+			}
+		}
+
+		var unknown []*ast.KeyValueExpr
+		if info.existing != nil {
+			unknown = f.unknownKeys(t, info, existing)
+		}
+
+		newlit := &ast.CompositeLit{Lbrace: f.pos}
+		if info.existing != nil {
+			// complete the user's literal in place
+			f.fixExprPos(info.existing.Type)
+			newlit = info.existing
+			newlit.Lbrace = f.pos
+			newlit.Elts = nil
+		} else if !info.hideType && info.name != nil {
+			typeName, ok := TypeString(f.pkg, f.importNames, info.name)
+			if !ok {
+				return nil
+			}
+			newlit.Type = ast.NewIdent(typeName)
+			if info.isPointer {
+				newlit.Type.(*ast.Ident).Name = "&" + newlit.Type.(*ast.Ident).Name
+			}
+		} else if !info.hideType && info.name == nil {
+			typeName, ok := TypeString(f.pkg, f.importNames, t)
+			if !ok {
+				return nil
+			}
+			newlit.Type = ast.NewIdent(typeName)
+		}
+
+		if info.scope.beyond() {
+			return newlit
+		}
+
+		// values written by the user are completed even for recursive types
+		var key types.Type = t
+		if info.name != nil {
+			key = info.name
+		}
+		if info.existing == nil && onStack(visited, key) {
+			return newlit
+		}
+		visited = append(visited, key)
+
+		lines := 0
+		imported := isImported(f.pkg, info.name)
+		var parent types.Type = t
+		if info.name != nil {
+			parent = info.name
+		}
+		scope := info.scope.enter(info.name, f.opts.TypeFilters)
+
+		var ctorValues map[string]string
+		if f.opts.CtorDefaults && info.name != nil {
+			ctorValues = f.constructorDefaults(info.name)
+		}
+
+		proto := isProtoMessage(t)
+
+		for i := 0; i < t.NumFields(); i++ {
+			field := t.Field(i)
+			// don't fill the field if it a gRPC system field
+			if hasPrefix(field.Name(), f.opts.Format.SkipPrefixes) {
+				continue
+			}
+			skip, inner := scope.skip(field, t.Tag(i))
+			if proto && isProtoInternal(field) {
+				skip = true
+			}
+			if kv, ok := existing[field.Name()]; ok {
+				f.pos++
+				lines++
+				if lit, ok := nestedLit(kv.Value, field.Type()); ok {
+					f.fixExprPos(kv.Key)
+					if u, ok := kv.Value.(*ast.UnaryExpr); ok {
+						u.OpPos = f.pos
+					}
+					f.zero(litInfo{typ: field.Type(), existing: lit, scope: inner}, visited)
+				} else {
+					f.fixExprPos(kv)
+				}
+				newlit.Elts = append(newlit.Elts, kv)
+			} else if !skip && (!imported || field.Exported()) {
+				f.pos++
+				k := &ast.Ident{Name: field.Name(), NamePos: f.pos}
+				var v ast.Expr
+				if value, ok := ctorValues[field.Name()]; ok {
+					v = &ast.Ident{Name: value, NamePos: f.pos}
+				} else {
+					v = f.zero(litInfo{typ: field.Type(), name: nil, scope: inner, field: field, hint: field.Name(), tag: t.Tag(i), parent: parent}, visited)
+				}
+				if lit, ok := v.(*ast.CompositeLit); ok && inner.leading && len(lit.Elts) == 0 {
+					// no included field below
+					v = nil
+				}
+				if v != nil {
+					lines++
+					newlit.Elts = append(newlit.Elts, &ast.KeyValueExpr{
+						Key:   k,
+						Value: v,
+					})
+				} else {
+					f.pos--
+				}
+			}
+		}
+		for _, kv := range unknown {
+			f.pos++
+			lines++
+			f.fixExprPos(kv)
+			newlit.Elts = append(newlit.Elts, kv)
+		}
+		if lines > 0 {
+			f.lines += lines + 2
+			f.pos++
+		}
+		newlit.Rbrace = f.pos
+		return newlit
+
+	default:
+		panic(fmt.Sprintf("unexpected type %T", t))
+	}
+}
+
+// unknownKeys records the keys of the user's literal that are not fields
+// of t. Depending on the options they are renamed to a similar missing
+// field, which is then filled like any existing key, or pruned. The keys
+// to keep as they are are returned in source order.
+func (f *Filler) unknownKeys(t *types.Struct, info litInfo, existing map[string]*ast.KeyValueExpr) (keep []*ast.KeyValueExpr) {
+	fields := make(map[string]bool, t.NumFields())
+	var missing []string
+	for i := 0; i < t.NumFields(); i++ {
+		name := t.Field(i).Name()
+		fields[name] = true
+		if _, ok := existing[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+
+	typ := info.typ
+	if info.name != nil {
+		typ = info.name
+	}
+	for _, e := range info.existing.Elts {
+		kv := e.(*ast.KeyValueExpr)
+		key := kv.Key.(*ast.Ident)
+		if fields[key.Name] {
+			continue
+		}
+		u := UnknownField{KV: kv, Name: key.Name, Pos: key.Pos(), Type: typ, Similar: similarName(key.Name, missing)}
+		switch {
+		case f.opts.RenameUnknown && u.Similar != "":
+			delete(existing, key.Name)
+			existing[u.Similar] = kv
+			key.Name = u.Similar
+			u.Renamed = true
+			for i, name := range missing {
+				if name == u.Similar {
+					missing = append(missing[:i], missing[i+1:]...)
+					break
+				}
+			}
+		case f.opts.PruneUnknown:
+			u.Pruned = true
+		default:
+			keep = append(keep, kv)
+		}
+		f.unknown = append(f.unknown, u)
+	}
+	return
+}
+
+// enumConst returns the constant to use for a value of the named basic
+// type t, among those of enumConsts. It returns nil if there is none, or
+// none with the zero value unless the first declared one is wanted.
+func (f *Filler) enumConst(t *types.Named) ast.Expr {
+	for _, c := range f.enumConsts(t) {
+		if !f.opts.EnumFirst && !IsZeroConst(c.Val()) {
+			continue
+		}
+		return f.constIdent(c)
+	}
+	return nil
+}
+
+// enumConsts returns the package level constants of exactly the named
+// basic type t in the defining package and the packages imported by the
+// file, in declaration order, those of the defining package first.
+func (f *Filler) enumConsts(t *types.Named) []*types.Const {
+	pkgs := append([]*types.Package{f.pkg, t.Obj().Pkg()}, f.pkg.Imports()...)
+	seen := make(map[*types.Package]bool)
+	var consts []*types.Const
+	for _, pkg := range pkgs {
+		if pkg == nil || seen[pkg] {
+			continue
+		}
+		seen[pkg] = true
+		// constants of a package imported for its side effects only
+		// cannot be referred to
+		if name, imported := f.importNames[pkg.Path()]; pkg != f.pkg && (!imported || name == "_") {
+			continue
+		}
+		scope := pkg.Scope()
+		for _, name := range scope.Names() {
+			c, ok := scope.Lookup(name).(*types.Const)
+			if !ok || !types.Identical(c.Type(), t) || (pkg != f.pkg && !c.Exported()) {
+				continue
+			}
+			consts = append(consts, c)
+		}
+	}
+	// declaration order, constants of the defining package first
+	sort.SliceStable(consts, func(i, j int) bool {
+		pi, pj := consts[i].Pkg() == t.Obj().Pkg(), consts[j].Pkg() == t.Obj().Pkg()
+		if pi != pj {
+			return pi
+		}
+		return consts[i].Pos() < consts[j].Pos()
+	})
+	return consts
+}
+
+// constIdent returns the identifier of c, qualified if c is imported
+// other than with a dot import
+func (f *Filler) constIdent(c *types.Const) ast.Expr {
+	name := c.Name()
+	if c.Pkg() != f.pkg {
+		if qual := f.importNames[c.Pkg().Path()]; qual != "." {
+			name = qual + "." + name
+		}
+	}
+	return &ast.Ident{Name: name, NamePos: f.pos}
+}
+
+// sequence is a interface that abstracts
+// between *types.Slice and *types.Array
+type sequence interface {
+	Elem() types.Type
+}
+
+func (f *Filler) fillSequence(info litInfo, visited []types.Type, t sequence, length ast.Expr) ast.Expr {
+	if info.existing != nil {
+		return f.completeElems(info, visited, t.Elem(), "[]")
+	}
+	lit := &ast.CompositeLit{Lbrace: f.pos}
+	if !info.hideType {
+		typeName, ok := TypeString(f.pkg, f.importNames, t.Elem())
+		if !ok {
+			return nil
+		}
+		lit.Type = &ast.ArrayType{
+			Lbrack: f.pos,
+			Len:    length,
+			Elt:    ast.NewIdent(typeName),
+		}
+	}
+	n := f.sequenceLen(t)
+	if n > 0 && !info.scope.beyond() {
+		lit.Elts = make([]ast.Expr, 0, n)
+		for i := int64(0); i < n; i++ {
+			f.pos++
+			elemInfo := litInfo{typ: t.Elem().Underlying(), hideType: true, scope: info.scope.elem("[]"), hint: info.hint}
+			elemInfo.name, _ = t.Elem().(*types.Named)
+			if v := f.zero(elemInfo, visited); v != nil {
+				lit.Elts = append(lit.Elts, v)
+			}
+		}
+		f.lines += len(lit.Elts)
+	}
+	f.lines += 2
+	f.pos++
+	lit.Rbrace = f.pos
+	return lit
+}
+
+// completeElems completes the struct literals written by the user as the
+// elements of info.existing, an array, slice or map literal with elements
+// of type elem. No element is added, step is shown in the reported paths.
+func (f *Filler) completeElems(info litInfo, visited []types.Type, elem types.Type, step string) ast.Expr {
+	lit := info.existing
+	if info.scope.beyond() {
+		f.fixExprPos(lit)
+		return lit
+	}
+	f.fixExprPos(lit.Type)
+	lit.Lbrace = f.pos
+	for _, e := range lit.Elts {
+		f.pos++
+		value := e
+		if kv, ok := e.(*ast.KeyValueExpr); ok {
+			f.fixExprPos(kv.Key)
+			value = kv.Value
+		}
+		x, ok := nestedLit(value, elem)
+		if !ok {
+			f.fixExprPos(value)
+			continue
+		}
+		if u, ok := value.(*ast.UnaryExpr); ok {
+			u.OpPos = f.pos
+		}
+		f.zero(litInfo{typ: elem, existing: x, scope: info.scope.elem(step), hint: info.hint}, visited)
+	}
+	f.pos++
+	lit.Rbrace = f.pos
+	return lit
+}
+
+// sequenceLen returns the number of elements to fill a literal of type t
+// with: all of them for an array, none for a slice unless values are made
+// up, one example or one to three random ones
+func (f *Filler) sequenceLen(t sequence) int64 {
+	if arr, ok := t.(*types.Array); ok {
+		return arr.Len()
+	}
+	switch f.opts.Values {
+	case ValuesExample:
+		return 1
+	case ValuesRandom:
+		return 1 + f.rng.Int63n(3)
+	}
+	return 0
+}
+
+func (f *Filler) fixExprPos(expr ast.Expr) {
+	switch expr := expr.(type) {
+	case nil:
+		// ignore
+	case *ast.BasicLit:
+		expr.ValuePos = f.pos
+	case *ast.BinaryExpr:
+		f.fixExprPos(expr.X)
+		expr.OpPos = f.pos
+		f.fixExprPos(expr.Y)
+	case *ast.CallExpr:
+		f.fixExprPos(expr.Fun)
+		expr.Lparen = f.pos
+		for _, arg := range expr.Args {
+			f.fixExprPos(arg)
+		}
+		expr.Rparen = f.pos
+	case *ast.CompositeLit:
+		f.fixExprPos(expr.Type)
+		expr.Lbrace = f.pos
+		for _, e := range expr.Elts {
+			f.pos++
+			f.fixExprPos(e)
+		}
+		if l := len(expr.Elts); l > 0 {
+			f.lines += l + 2
+		}
+		f.pos++
+		expr.Rbrace = f.pos
+	case *ast.Ellipsis:
+		expr.Ellipsis = f.pos
+	case *ast.FuncLit:
+		expr.Type.Func = f.pos
+	case *ast.Ident:
+		expr.NamePos = f.pos
+	case *ast.IndexExpr:
+		f.fixExprPos(expr.X)
+		expr.Lbrack = f.pos
+		f.fixExprPos(expr.Index)
+		expr.Rbrack = f.pos
+	case *ast.KeyValueExpr:
+		f.fixExprPos(expr.Key)
+		f.fixExprPos(expr.Value)
+	case *ast.ParenExpr:
+		expr.Lparen = f.pos
+	case *ast.SelectorExpr:
+		f.fixExprPos(expr.X)
+		expr.Sel.NamePos = f.pos
+	case *ast.SliceExpr:
+		f.fixExprPos(expr.X)
+		expr.Lbrack = f.pos
+		f.fixExprPos(expr.Low)
+		f.fixExprPos(expr.High)
+		f.fixExprPos(expr.Max)
+		expr.Rbrack = f.pos
+	case *ast.StarExpr:
+		expr.Star = f.pos
+		f.fixExprPos(expr.X)
+	case *ast.UnaryExpr:
+		expr.OpPos = f.pos
+		f.fixExprPos(expr.X)
+	}
+}
+
+// basicZero returns the zero value of a basic type
+func (f *Filler) basicZero(t *types.Basic) ast.Expr {
+	switch t.Kind() {
+	case types.Bool:
+		return &ast.Ident{Name: "false", NamePos: f.pos}
+	case types.Int, types.Int8, types.Int16, types.Int32, types.Int64:
+		return &ast.BasicLit{Value: "0", ValuePos: f.pos}
+	case types.Uint, types.Uint8, types.Uint16, types.Uint32, types.Uint64:
+		return &ast.BasicLit{Value: "0", ValuePos: f.pos}
+	case types.Uintptr:
+		return &ast.BasicLit{Value: "uintptr(0)", ValuePos: f.pos}
+	case types.UnsafePointer:
+		return &ast.BasicLit{Value: "unsafe.Pointer(uintptr(0))", ValuePos: f.pos}
+	case types.Float32, types.Float64:
+		return &ast.BasicLit{Value: f.opts.Format.Float, ValuePos: f.pos}
+	case types.Complex64, types.Complex128:
+		return &ast.BasicLit{Value: "(0 + 0i)", ValuePos: f.pos}
+	case types.String:
+		return &ast.BasicLit{Value: `""`, ValuePos: f.pos}
+	default:
+		// Cannot create an expression for an invalid type.
+		return nil
+	}
+}
+
+// onStack reports whether a type identical to t is being filled, t being
+// the named type of the struct, or the struct itself if it is anonymous.
+// Identity rather than pointer equality is needed for instantiated
+// generic types, which get a new type for each instance.
+func onStack(visited []types.Type, t types.Type) bool {
+	for _, typ := range visited {
+		if types.Identical(t, typ) {
+			return true
+		}
+	}
+	return false
+}
+
+func isImported(pkg *types.Package, n *types.Named) bool {
+	return n != nil && pkg != n.Obj().Pkg()
+}
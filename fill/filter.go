@@ -0,0 +1,207 @@
+package fill
+
+import (
+	"fmt"
+	"go/types"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// FieldFilter selects the fields to fill. Patterns are matched against
+// the whole path of a field from the filled literal, e.g. Addr.List.name.
+type FieldFilter struct {
+	include      []*pathPattern // fill only the fields matching one of them, all if empty
+	exclude      []*pathPattern // do not fill the fields matching one of them
+	exportedOnly bool           // fill exported fields only, even of local types
+	withTag      []string       // fill only the fields having one of these tag keys
+	skipIgnored  []string       // do not fill the fields whose tag value for one of these keys is "-"
+}
+
+// NewFieldFilter returns a filter of the fields whose path matches one of
+// the include patterns, if any, and none of the exclude ones. Fields are
+// also selected by export status and tag keys, see FieldFilter.
+func NewFieldFilter(include, exclude []string, exportedOnly bool, withTag, skipIgnored []string) (*FieldFilter, error) {
+	ff := &FieldFilter{
+		exportedOnly: exportedOnly,
+		withTag:      withTag,
+		skipIgnored:  skipIgnored,
+	}
+	var err error
+	if ff.include, err = newPathPatterns(include); err != nil {
+		return nil, err
+	}
+	if ff.exclude, err = newPathPatterns(exclude); err != nil {
+		return nil, err
+	}
+	return ff, nil
+}
+
+// pathPattern is a regular expression matching a whole field path
+type pathPattern struct {
+	re     *regexp.Regexp
+	prefix string // literal prefix of the expression
+}
+
+func newPathPattern(expr string) (*pathPattern, error) {
+	re, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid field pattern %q: %v", expr, err)
+	}
+	raw, _ := regexp.Compile(expr)
+	prefix, _ := raw.LiteralPrefix()
+	return &pathPattern{re: re, prefix: prefix}, nil
+}
+
+func newPathPatterns(exprs []string) ([]*pathPattern, error) {
+	patterns := make([]*pathPattern, 0, len(exprs))
+	for _, expr := range exprs {
+		p, err := newPathPattern(expr)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, p)
+	}
+	return patterns, nil
+}
+
+// mayMatchBelow reports whether p may match a field nested in path
+func (p *pathPattern) mayMatchBelow(path string) bool {
+	path += "."
+	return strings.HasPrefix(p.prefix, path) || strings.HasPrefix(path, p.prefix)
+}
+
+// fieldScope is where a literal sits in the filled tree
+type fieldScope struct {
+	path     string       // path of the field holding the literal, empty for the filled literal
+	shown    string       // path as reported, with the element steps, e.g. Items[].Next
+	filter   *FieldFilter // filter applying to the fields of the literal, nil for none
+	included bool         // the literal is inside a field selected by an include pattern
+	leading  bool         // the literal is only filled for the included fields below it
+
+	depth  int            // nesting depth of the literal, 0 for the filled literal
+	limit  int            // depth from which literals are left empty, 0 for no limit
+	depths map[string]int // depth limits by field path, relative to the field
+}
+
+// field returns the scope of the value of the field called name
+func (s fieldScope) field(name string) fieldScope {
+	path, shown := name, name
+	if s.path != "" {
+		path = s.path + "." + name
+	}
+	if s.shown != "" {
+		shown = s.shown + "." + name
+	}
+	s.path, s.shown = path, shown
+	s.leading = false
+	s.depth++
+	if limit, ok := s.depths[path]; ok {
+		s.limit = s.depth + limit
+	}
+	return s
+}
+
+// elem returns the scope of the elements of an array, slice or map,
+// step being shown after the path: [] for elements, [key] for map values
+// and {key} for map keys
+func (s fieldScope) elem(step string) fieldScope {
+	s.shown += step
+	s.depth++
+	return s
+}
+
+// beyond reports whether the literal is too deep to be filled: pointers,
+// slices, maps, channels and functions are nil, structs and arrays empty
+func (s fieldScope) beyond() bool {
+	return s.limit > 0 && s.depth >= s.limit
+}
+
+// skip reports whether field, with the given tag, is not to be filled.
+// The returned scope is the one of the field's value.
+func (s fieldScope) skip(field *types.Var, tag string) (bool, fieldScope) {
+	inner := s.field(field.Name())
+	ff := s.filter
+	if ff == nil {
+		return false, inner
+	}
+
+	if ff.exportedOnly && !field.Exported() {
+		return true, inner
+	}
+	st := reflect.StructTag(tag)
+	if len(ff.withTag) > 0 {
+		found := false
+		for _, key := range ff.withTag {
+			if _, ok := st.Lookup(key); ok {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return true, inner
+		}
+	}
+	for _, key := range ff.skipIgnored {
+		if v, ok := st.Lookup(key); ok && v == "-" {
+			return true, inner
+		}
+	}
+	for _, p := range ff.exclude {
+		if p.re.MatchString(inner.path) {
+			return true, inner
+		}
+	}
+
+	if len(ff.include) == 0 || s.included {
+		return false, inner
+	}
+	for _, p := range ff.include {
+		if p.re.MatchString(inner.path) {
+			inner.included = true
+			return false, inner
+		}
+	}
+	// keep the fields leading to an included one
+	if hasFields(field.Type()) {
+		for _, p := range ff.include {
+			if p.mayMatchBelow(inner.path) {
+				inner.leading = true
+				return false, inner
+			}
+		}
+	}
+	return true, inner
+}
+
+// enter returns the scope of the fields of a struct of type named. Types
+// with a filter of their own start a new scope, their field paths are
+// relative to them.
+func (s fieldScope) enter(named *types.Named, typeFilters map[string]*FieldFilter) fieldScope {
+	if named == nil || len(typeFilters) == 0 {
+		return s
+	}
+	if ff, ok := typeFilters[qualifiedName(named)]; ok {
+		s.path, s.filter, s.included, s.leading = "", ff, false, false
+	}
+	return s
+}
+
+// hasFields reports whether typ is a struct or a pointer to one
+func hasFields(typ types.Type) bool {
+	if p, ok := typ.Underlying().(*types.Pointer); ok {
+		typ = p.Elem()
+	}
+	_, ok := typ.Underlying().(*types.Struct)
+	return ok
+}
+
+// qualifiedName returns the name of a type qualified by its package path,
+// e.g. time.Time or golang.org/x/tools/go/packages.Config
+func qualifiedName(named *types.Named) string {
+	obj := named.Obj()
+	if obj.Pkg() == nil {
+		return obj.Name()
+	}
+	return obj.Pkg().Path() + "." + obj.Name()
+}
@@ -0,0 +1,111 @@
+package fill
+
+import (
+	"go/ast"
+	"go/build"
+	"go/types"
+	"sort"
+)
+
+// ways to fill interface fields, set by Options.Implementations
+const (
+	ImplementationsFill = "fill" // with the configured or only implementation
+	ImplementationsList = "list" // leave them nil and record the implementations found
+)
+
+// Implementation is a type implementing an interface
+type Implementation struct {
+	Named   *types.Named
+	Pointer bool // only a pointer to Named implements the interface
+}
+
+// InterfaceField is an interface field with the implementations
+// found for it, for ImplementationsList
+type InterfaceField struct {
+	Path            string
+	Interface       *types.Named
+	Implementations []Implementation
+}
+
+// implementationOf returns a filled literal of the implementation of the
+// named interface t to use: the configured one, or the only one found
+// in the package and the non-standard packages the file imports. Other
+// packages are not searched, the file could not refer to their types.
+// Oneofs of protobuf messages take the wrapper of their first member.
+// It returns nil if there is none to choose.
+func (f *Filler) implementationOf(t *types.Named, info litInfo, visited []types.Type) ast.Expr {
+	iface := t.Underlying().(*types.Interface)
+	if iface.Empty() {
+		return nil
+	}
+	impls := f.implementations(iface)
+	if isOneof(info.tag) {
+		// oneof wrappers in the order of the members
+		sort.SliceStable(impls, func(i, j int) bool {
+			return impls[i].Named.Obj().Pos() < impls[j].Named.Obj().Pos()
+		})
+	}
+	if f.opts.Implementations == ImplementationsList {
+		f.ifaceFields = append(f.ifaceFields, InterfaceField{Path: info.scope.path, Interface: t, Implementations: impls})
+		return nil
+	}
+
+	var impl *Implementation
+	if name, ok := f.opts.UseImplementation[qualifiedName(t)]; ok {
+		for i := range impls {
+			if qualifiedName(impls[i].Named) == name {
+				impl = &impls[i]
+			}
+		}
+	} else if len(impls) == 1 || len(impls) > 0 && isOneof(info.tag) {
+		// the wrapper of the first member of a oneof
+		impl = &impls[0]
+	}
+	if impl == nil {
+		return nil
+	}
+	return f.zero(litInfo{typ: impl.Named.Underlying(), name: impl.Named, isPointer: impl.Pointer, scope: info.scope}, visited)
+}
+
+// implementations returns the struct types implementing iface declared in
+// the filled package and the packages imported by the file, leaving out
+// the standard library
+func (f *Filler) implementations(iface *types.Interface) []Implementation {
+	pkgs := []*types.Package{f.pkg}
+	for _, pkg := range f.pkg.Imports() {
+		if _, imported := f.importNames[pkg.Path()]; imported && !isStd(pkg.Path()) {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+
+	var impls []Implementation
+	for _, pkg := range pkgs {
+		scope := pkg.Scope()
+		for _, name := range scope.Names() {
+			tn, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || tn.IsAlias() || (pkg != f.pkg && !tn.Exported()) {
+				continue
+			}
+			named, ok := tn.Type().(*types.Named)
+			if !ok || named.TypeParams().Len() > 0 {
+				continue
+			}
+			if _, ok := named.Underlying().(*types.Struct); !ok {
+				continue
+			}
+			switch {
+			case types.Implements(named, iface):
+				impls = append(impls, Implementation{Named: named})
+			case types.Implements(types.NewPointer(named), iface):
+				impls = append(impls, Implementation{Named: named, Pointer: true})
+			}
+		}
+	}
+	return impls
+}
+
+// isStd reports whether path is the path of a standard library package
+func isStd(path string) bool {
+	p, err := build.Default.Import(path, "", build.FindOnly)
+	return err == nil && p.Goroot
+}
@@ -0,0 +1,213 @@
+package fill
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// presetAction is what a value of a well-known type is filled with
+type presetAction int
+
+const (
+	presetZero presetAction = iota // the shortest zero value, e.g. T{} or nil
+	presetOmit                     // leave the field out
+	presetExpr                     // a given expression
+)
+
+// Preset tells how to fill a value of a well-known type. Expressions
+// refer to packages by their name, which is replaced by the name the
+// file imports the package under.
+type Preset struct {
+	action presetAction
+	expr   string
+	given  bool // by the user, not built in
+}
+
+// DefaultPresets is the built-in registry of presets by fully qualified
+// type, as written by types.TypeString with package paths
+var DefaultPresets = map[string]Preset{
+	"time.Time":       {action: presetZero},
+	"time.Duration":   {action: presetZero},
+	"context.Context": {action: presetExpr, expr: "context.TODO()"},
+	"error":           {action: presetZero},
+
+	// values that must not be copied
+	"sync.Mutex":     {action: presetOmit},
+	"sync.RWMutex":   {action: presetOmit},
+	"sync.WaitGroup": {action: presetOmit},
+	"sync.Once":      {action: presetOmit},
+	"regexp.Regexp":  {action: presetOmit},
+
+	"net/url.URL":              {action: presetZero},
+	"*net/url.URL":             {action: presetZero},
+	"net.IP":                   {action: presetZero},
+	"math/big.Int":             {action: presetZero},
+	"*math/big.Int":            {action: presetExpr, expr: "new(big.Int)"},
+	"*regexp.Regexp":           {action: presetZero},
+	"encoding/json.RawMessage": {action: presetZero},
+
+	"*google.golang.org/protobuf/types/known/timestamppb.Timestamp":  {action: presetExpr, expr: "timestamppb.Now()"},
+	"*google.golang.org/protobuf/types/known/durationpb.Duration":    {action: presetExpr, expr: "&durationpb.Duration{}"},
+	"*google.golang.org/protobuf/types/known/wrapperspb.BoolValue":   {action: presetExpr, expr: "&wrapperspb.BoolValue{}"},
+	"*google.golang.org/protobuf/types/known/wrapperspb.BytesValue":  {action: presetExpr, expr: "&wrapperspb.BytesValue{}"},
+	"*google.golang.org/protobuf/types/known/wrapperspb.DoubleValue": {action: presetExpr, expr: "&wrapperspb.DoubleValue{}"},
+	"*google.golang.org/protobuf/types/known/wrapperspb.FloatValue":  {action: presetExpr, expr: "&wrapperspb.FloatValue{}"},
+	"*google.golang.org/protobuf/types/known/wrapperspb.Int32Value":  {action: presetExpr, expr: "&wrapperspb.Int32Value{}"},
+	"*google.golang.org/protobuf/types/known/wrapperspb.Int64Value":  {action: presetExpr, expr: "&wrapperspb.Int64Value{}"},
+	"*google.golang.org/protobuf/types/known/wrapperspb.StringValue": {action: presetExpr, expr: "&wrapperspb.StringValue{}"},
+	"*google.golang.org/protobuf/types/known/wrapperspb.UInt32Value": {action: presetExpr, expr: "&wrapperspb.UInt32Value{}"},
+	"*google.golang.org/protobuf/types/known/wrapperspb.UInt64Value": {action: presetExpr, expr: "&wrapperspb.UInt64Value{}"},
+
+	"database/sql.NullBool":    {action: presetZero},
+	"database/sql.NullByte":    {action: presetZero},
+	"database/sql.NullFloat64": {action: presetZero},
+	"database/sql.NullInt16":   {action: presetZero},
+	"database/sql.NullInt32":   {action: presetZero},
+	"database/sql.NullInt64":   {action: presetZero},
+	"database/sql.NullString":  {action: presetZero},
+	"database/sql.NullTime":    {action: presetZero},
+	"database/sql.Null":        {action: presetZero},
+}
+
+// ParsePreset parses a preset given by the user as type=omit, type=zero
+// or type=expression. Presets given by the user win over made-up values.
+func ParsePreset(s string) (string, Preset, error) {
+	typ, value, ok := strings.Cut(s, "=")
+	typ, value = strings.TrimSpace(typ), strings.TrimSpace(value)
+	if !ok || typ == "" || value == "" {
+		return "", Preset{}, fmt.Errorf("invalid preset %q, want type=omit, type=zero or type=expression", s)
+	}
+	switch value {
+	case "omit":
+		return typ, Preset{action: presetOmit, given: true}, nil
+	case "zero":
+		return typ, Preset{action: presetZero, given: true}, nil
+	}
+	if _, err := parser.ParseExpr(value); err != nil {
+		return "", Preset{}, fmt.Errorf("invalid preset expression %q: %v", value, err)
+	}
+	return typ, Preset{action: presetExpr, expr: value, given: true}, nil
+}
+
+// String returns p as given to ParsePreset, after the type
+func (p Preset) String() string {
+	switch p.action {
+	case presetOmit:
+		return "omit"
+	case presetExpr:
+		return p.expr
+	default:
+		return "zero"
+	}
+}
+
+// presetKey returns the key of typ in the preset registry
+func presetKey(typ types.Type) string {
+	return types.TypeString(typ, func(p *types.Package) string { return p.Path() })
+}
+
+// lookupPreset returns the preset for typ. Instances of generic types
+// fall back to the preset of their generic type.
+func (f *Filler) lookupPreset(typ types.Type) (Preset, bool) {
+	if len(f.opts.Presets) == 0 {
+		return Preset{}, false
+	}
+	if p, ok := f.opts.Presets[presetKey(typ)]; ok {
+		return p, true
+	}
+	if named, ok := typ.(*types.Named); ok && named.TypeArgs().Len() > 0 {
+		p, ok := f.opts.Presets[qualifiedName(named.Origin())]
+		return p, ok
+	}
+	return Preset{}, false
+}
+
+// preset returns the value of p for info, nil to leave the field out.
+// Elements of arrays, slices and maps cannot be left out, they take the
+// zero value instead, as do expressions referring to packages the file
+// does not import.
+func (f *Filler) preset(p Preset, info litInfo) ast.Expr {
+	switch p.action {
+	case presetOmit:
+		if !info.hideType {
+			return nil
+		}
+	case presetExpr:
+		if x := f.presetExpr(p.expr); x != nil {
+			return x
+		}
+	}
+	return f.shortZero(info.typ)
+}
+
+// shortZero returns the zero value of typ without filling it, e.g. T{}.
+// It is the true zero value, never one made up with Options.Values.
+func (f *Filler) shortZero(typ types.Type) ast.Expr {
+	switch u := typ.Underlying().(type) {
+	case *types.Basic:
+		return f.basicZero(u)
+	case *types.Struct, *types.Array:
+		typeName, ok := TypeString(f.pkg, f.importNames, typ)
+		if !ok {
+			return nil
+		}
+		return &ast.CompositeLit{Type: ast.NewIdent(typeName), Lbrace: f.pos, Rbrace: f.pos}
+	default:
+		return &ast.Ident{Name: "nil", NamePos: f.pos}
+	}
+}
+
+// presetExpr returns expr with the packages it refers to renamed to the
+// names the file imports them under, or nil if one of them is not imported
+func (f *Filler) presetExpr(expr string) ast.Expr {
+	x, err := parser.ParseExpr(expr)
+	if err != nil {
+		return nil
+	}
+	imported := true
+	ast.Inspect(x, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		id, ok := sel.X.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		name, ok := f.importName(id.Name)
+		if !ok {
+			imported = false
+			return false
+		}
+		id.Name = name
+		return true
+	})
+	if !imported {
+		return nil
+	}
+	var buf bytes.Buffer
+	if err := format.Node(&buf, token.NewFileSet(), x); err != nil {
+		return nil
+	}
+	return &ast.Ident{Name: buf.String(), NamePos: f.pos}
+}
+
+// importName returns the name the file imports the package called pkgName
+// under, pkgName being the name the package declares
+func (f *Filler) importName(pkgName string) (string, bool) {
+	for _, pkg := range f.pkg.Imports() {
+		if pkg.Name() != pkgName {
+			continue
+		}
+		if name, ok := f.importNames[pkg.Path()]; ok && name != "." && name != "_" {
+			return name, true
+		}
+	}
+	return "", false
+}
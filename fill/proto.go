@@ -0,0 +1,39 @@
+package fill
+
+import (
+	"go/types"
+	"reflect"
+)
+
+// the type of the state field of the messages generated by protoc-gen-go,
+// an alias, which depending on the Go version shows as either one
+var protoMessageState = map[string]bool{
+	"google.golang.org/protobuf/runtime/protoimpl.MessageState": true,
+	"google.golang.org/protobuf/internal/impl.MessageState":     true,
+}
+
+// isProtoMessage reports whether t is a message generated by protoc-gen-go,
+// which holds its state in a protoimpl.MessageState field
+func isProtoMessage(t *types.Struct) bool {
+	for i := 0; i < t.NumFields(); i++ {
+		if protoMessageState[presetKey(t.Field(i).Type())] {
+			return true
+		}
+	}
+	return false
+}
+
+// isProtoInternal reports whether field is an internal field of a message,
+// such as state, sizeCache or unknownFields, that is never to be filled.
+// protoc-gen-go only generates unexported fields for those, most of them
+// of aliased types that can't be told apart from int32 or []byte.
+func isProtoInternal(field *types.Var) bool {
+	return !field.Exported()
+}
+
+// isOneof reports whether a field with the given tag holds a oneof, whose
+// interface is implemented by a wrapper type per member
+func isOneof(tag string) bool {
+	_, ok := reflect.StructTag(tag).Lookup("protobuf_oneof")
+	return ok
+}
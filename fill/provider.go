@@ -0,0 +1,71 @@
+package fill
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"reflect"
+)
+
+// FieldContext describes the field a value is filled for
+type FieldContext struct {
+	Path   string            // path of the field from the filled literal, e.g. Addr.City
+	Field  *types.Var        // the field
+	Tag    reflect.StructTag // tag of the field
+	Struct types.Type        // type of the struct holding the field, named if it is
+
+	// ImportName returns the name the file imports the package with the
+	// given path under, false if the file does not import it
+	ImportName func(pkgPath string) (string, bool)
+}
+
+// ValueProvider returns the value to fill a field with, false to leave
+// it to the next provider and then to the Filler. A nil value leaves the
+// field out.
+type ValueProvider func(ctx FieldContext) (ast.Expr, bool)
+
+// providers are consulted in registration order
+var providers []ValueProvider
+
+// RegisterValueProvider adds p to the providers consulted for every field
+// before the built-in values. It is meant to be called from an init
+// function, before any literal is filled.
+func RegisterValueProvider(p ValueProvider) {
+	providers = append(providers, p)
+}
+
+// provide returns the value of the first provider answering for the
+// field described by info
+func (f *Filler) provide(info litInfo) (ast.Expr, bool) {
+	ctx := FieldContext{
+		Path:   info.scope.path,
+		Field:  info.field,
+		Tag:    reflect.StructTag(info.tag),
+		Struct: info.parent,
+		ImportName: func(pkgPath string) (string, bool) {
+			if f.pkg != nil && pkgPath == f.pkg.Path() {
+				return "", true
+			}
+			name, ok := f.importNames[pkgPath]
+			return name, ok
+		},
+	}
+	for _, p := range providers {
+		x, ok := p(ctx)
+		if !ok {
+			continue
+		}
+		if x == nil {
+			return nil, true
+		}
+		// positions of the provided expression mean nothing in the file
+		var buf bytes.Buffer
+		if err := format.Node(&buf, token.NewFileSet(), x); err != nil {
+			return nil, true
+		}
+		return &ast.Ident{Name: buf.String(), NamePos: f.pos}, true
+	}
+	return nil, false
+}
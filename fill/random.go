@@ -0,0 +1,67 @@
+package fill
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"strconv"
+)
+
+// random returns a random value of the type described by info, false for
+// the types it makes up none for. Named basic types with constants take
+// one of them.
+func (f *Filler) random(info litInfo) (ast.Expr, bool) {
+	typ := info.typ
+	if info.name != nil {
+		typ = info.name
+	}
+	switch presetKey(typ) {
+	case "time.Time":
+		x := f.presetExpr(fmt.Sprintf("time.Date(%d, %d, %d, %d, %d, %d, 0, time.UTC)",
+			2000+f.rng.Intn(30), 1+f.rng.Intn(12), 1+f.rng.Intn(28), f.rng.Intn(24), f.rng.Intn(60), f.rng.Intn(60)))
+		return x, x != nil
+	case "time.Duration":
+		x := f.presetExpr(fmt.Sprintf("%d * time.Second", 1+f.rng.Intn(3600)))
+		return x, x != nil
+	}
+
+	if named, ok := typ.(*types.Named); ok && named.Obj().Pkg() != nil {
+		if _, ok := named.Underlying().(*types.Basic); ok {
+			if consts := f.enumConsts(named); len(consts) > 0 {
+				return f.constIdent(consts[f.rng.Intn(len(consts))]), true
+			}
+		}
+	}
+
+	b, ok := typ.Underlying().(*types.Basic)
+	if !ok {
+		return nil, false
+	}
+	var value string
+	switch info := b.Info(); {
+	case info&types.IsBoolean != 0:
+		value = strconv.FormatBool(f.rng.Intn(2) == 0)
+	case info&types.IsString != 0:
+		value = strconv.Quote(f.randomString())
+	case info&types.IsInteger != 0:
+		max := int64(1000)
+		if basicBits(b) == 8 {
+			max = 100
+		}
+		value = strconv.FormatInt(1+f.rng.Int63n(max), 10)
+	case info&types.IsFloat != 0:
+		value = strconv.FormatFloat(float64(1+f.rng.Intn(100000))/100, 'f', 2, 64)
+	default:
+		return nil, false
+	}
+	return &ast.BasicLit{Value: value, ValuePos: f.pos}, true
+}
+
+// randomString returns a lowercase word of 5 to 10 letters
+func (f *Filler) randomString() string {
+	b := make([]byte, 5+f.rng.Intn(6))
+	for i := range b {
+		b[i] = byte('a' + f.rng.Intn(26))
+	}
+	return string(b)
+}
@@ -0,0 +1,85 @@
+package fill
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// inScope returns the variables, constants and functions in scope at the
+// filled literal, innermost first. Shadowed identifiers and those declared by
+// the statement of the literal are left out.
+func (f *Filler) inScope() []types.Object {
+	if f.scopeObjs != nil || f.scope == nil {
+		return f.scopeObjs
+	}
+	f.scopeObjs = []types.Object{}
+	seen := make(map[string]bool)
+	for s := f.scope; s != nil && s != types.Universe; s = s.Parent() {
+		for _, name := range s.Names() {
+			if name == "_" || seen[name] {
+				continue
+			}
+			seen[name] = true
+			_, obj := f.scope.LookupParent(name, f.scopePos)
+			switch obj := obj.(type) {
+			case *types.Var, *types.Const:
+				f.scopeObjs = append(f.scopeObjs, obj)
+			case *types.Func:
+				if sig := obj.Type().(*types.Signature); sig.TypeParams().Len() == 0 && obj.Name() != "main" {
+					f.scopeObjs = append(f.scopeObjs, obj)
+				}
+			}
+		}
+	}
+	return f.scopeObjs
+}
+
+// scopeValue returns an identifier in scope at the filled literal that is
+// assignable to the field described by info. One with a name similar to
+// the field's is preferred, e.g. userID for UserID; without one, fields
+// of basic types are left to their zero value, others take the innermost
+// identifier of the same type.
+func (f *Filler) scopeValue(info litInfo) (ast.Expr, bool) {
+	typ := info.field.Type()
+	if iface, ok := typ.Underlying().(*types.Interface); ok && iface.Empty() {
+		// anything is assignable
+		return nil, false
+	}
+
+	var names []string
+	byName := make(map[string]types.Object)
+	var same types.Object
+	for _, obj := range f.inScope() {
+		if !types.AssignableTo(obj.Type(), typ) {
+			continue
+		}
+		if strings.EqualFold(obj.Name(), info.field.Name()) {
+			return f.scopeIdent(obj), true
+		}
+		names = append(names, obj.Name())
+		byName[obj.Name()] = obj
+		if same == nil && types.Identical(obj.Type(), typ) {
+			same = obj
+		}
+	}
+	if name := similarName(info.field.Name(), names); name != "" {
+		return f.scopeIdent(byName[name]), true
+	}
+	if _, basic := typ.Underlying().(*types.Basic); same != nil && !basic {
+		return f.scopeIdent(same), true
+	}
+	return nil, false
+}
+
+func (f *Filler) scopeIdent(obj types.Object) ast.Expr {
+	return &ast.Ident{Name: obj.Name(), NamePos: f.pos}
+}
+
+// SetScope records pos as the position of the filled literal, whose scope
+// gives the identifiers used with UseScope
+func (f *Filler) SetScope(pos token.Pos) {
+	f.scope = f.pkg.Scope().Innermost(pos)
+	f.scopePos = pos
+}
@@ -0,0 +1,93 @@
+package fill
+
+import (
+	"go/ast"
+	"go/types"
+)
+
+// func literal bodies set by the func-body format setting, any other
+// setting being the statement to use
+const (
+	funcBodyPanic = "panic"
+	funcBodyZero  = "zero"
+)
+
+// funcStub returns a func literal of signature t: its parameters take the
+// names of the signature, or _, and its body panics, or returns the zero
+// values of the results, as the func-body format setting says
+func (f *Filler) funcStub(t *types.Signature) ast.Expr {
+	imported := make(map[string]bool, len(f.importNames))
+	for _, name := range f.importNames {
+		imported[name] = true
+	}
+
+	params := make([]*ast.Field, t.Params().Len())
+	for i := 0; i < t.Params().Len(); i++ {
+		p := t.Params().At(i)
+		typ := p.Type()
+		variadic := t.Variadic() && i == t.Params().Len()-1
+		if variadic {
+			typ = typ.(*types.Slice).Elem()
+		}
+		typeName, ok := TypeString(f.pkg, f.importNames, typ)
+		if !ok {
+			return nil
+		}
+		if variadic {
+			typeName = "..." + typeName
+		}
+		name := p.Name()
+		if name == "" || imported[name] {
+			// a parameter must not hide a package its type refers to
+			name = "_"
+		}
+		params[i] = &ast.Field{
+			Names: []*ast.Ident{ast.NewIdent(name)},
+			Type:  ast.NewIdent(typeName),
+		}
+	}
+	results := make([]*ast.Field, t.Results().Len())
+	for i := 0; i < t.Results().Len(); i++ {
+		typeName, ok := TypeString(f.pkg, f.importNames, t.Results().At(i).Type())
+		if !ok {
+			return nil
+		}
+		results[i] = &ast.Field{
+			Type: ast.NewIdent(typeName),
+		}
+	}
+
+	var body ast.Stmt
+	switch f.opts.Format.FuncBody {
+	case funcBodyZero:
+		if t.Results().Len() == 0 {
+			break
+		}
+		ret := &ast.ReturnStmt{Return: f.pos}
+		for i := 0; i < t.Results().Len(); i++ {
+			v := f.shortZero(t.Results().At(i).Type())
+			if v == nil {
+				return nil
+			}
+			ret.Results = append(ret.Results, v)
+		}
+		body = ret
+	case funcBodyPanic, "":
+		body = &ast.ExprStmt{X: &ast.Ident{Name: `panic("not implemented")`, NamePos: f.pos}}
+	default:
+		body = &ast.ExprStmt{X: &ast.Ident{Name: f.opts.Format.FuncBody, NamePos: f.pos}}
+	}
+	block := &ast.BlockStmt{Lbrace: f.pos, Rbrace: f.pos}
+	if body != nil {
+		block.List = []ast.Stmt{body}
+	}
+
+	return &ast.FuncLit{
+		Type: &ast.FuncType{
+			Func:    f.pos,
+			Params:  &ast.FieldList{Opening: f.pos, List: params, Closing: f.pos},
+			Results: &ast.FieldList{List: results},
+		},
+		Body: block,
+	}
+}
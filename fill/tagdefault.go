@@ -0,0 +1,158 @@
+package fill
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultTagKeys are the tag keys holding the default value of a field,
+// as used by creasty/defaults and caarlos0/env
+var defaultTagKeys = []string{"default", "envDefault"}
+
+// BadDefault is a default value of a field tag that cannot be used
+type BadDefault struct {
+	Field *types.Var
+	Value string
+	Err   error
+}
+
+// tagDefault returns the value of the default tag of the field described
+// by info, false if it has none. Defaults that cannot be used are recorded
+// and the field is filled as if it had none.
+func (f *Filler) tagDefault(info litInfo) (ast.Expr, bool) {
+	st := reflect.StructTag(info.tag)
+	for _, key := range defaultTagKeys {
+		value, ok := st.Lookup(key)
+		if !ok {
+			continue
+		}
+		x, err := f.defaultValue(info.field.Type(), value)
+		if err != nil {
+			f.badDefaults = append(f.badDefaults, BadDefault{Field: info.field, Value: value, Err: err})
+			return nil, false
+		}
+		return &ast.Ident{Name: x, NamePos: f.pos}, true
+	}
+	return nil, false
+}
+
+// defaultValue returns the source of a value of type typ given as text
+func (f *Filler) defaultValue(typ types.Type, text string) (string, error) {
+	if presetKey(typ) == "time.Duration" {
+		d, err := time.ParseDuration(text)
+		if err != nil {
+			return "", err
+		}
+		return f.durationString(d), nil
+	}
+
+	switch t := typ.Underlying().(type) {
+	case *types.Basic:
+		return basicValue(t, text)
+	case *types.Slice:
+		sliceType, ok := TypeString(f.pkg, f.importNames, typ)
+		if !ok {
+			return "", fmt.Errorf("cannot write the type %s", typ)
+		}
+		var elts []string
+		if text != "" {
+			for _, s := range strings.Split(text, ",") {
+				x, err := f.defaultValue(t.Elem(), strings.TrimSpace(s))
+				if err != nil {
+					return "", err
+				}
+				elts = append(elts, x)
+			}
+		}
+		return sliceType + "{" + strings.Join(elts, ", ") + "}", nil
+	default:
+		return "", fmt.Errorf("defaults of type %s are not supported", typ)
+	}
+}
+
+// basicValue returns the constant of type t given as text
+func basicValue(t *types.Basic, text string) (string, error) {
+	info := t.Info()
+	switch {
+	case info&types.IsBoolean != 0:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatBool(b), nil
+	case info&types.IsString != 0:
+		return strconv.Quote(text), nil
+	case info&types.IsUnsigned != 0:
+		n, err := strconv.ParseUint(text, 0, basicBits(t))
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatUint(n, 10), nil
+	case info&types.IsInteger != 0:
+		n, err := strconv.ParseInt(text, 0, basicBits(t))
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatInt(n, 10), nil
+	case info&types.IsFloat != 0:
+		x, err := strconv.ParseFloat(text, basicBits(t))
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatFloat(x, 'g', -1, basicBits(t)), nil
+	default:
+		return "", fmt.Errorf("defaults of type %s are not supported", t)
+	}
+}
+
+// basicBits returns the size in bits of a numeric type, 0 for int and uint
+func basicBits(t *types.Basic) int {
+	switch t.Kind() {
+	case types.Int8, types.Uint8:
+		return 8
+	case types.Int16, types.Uint16:
+		return 16
+	case types.Int32, types.Uint32, types.Float32:
+		return 32
+	case types.Int64, types.Uint64, types.Float64, types.Uintptr:
+		return 64
+	default:
+		return 0
+	}
+}
+
+// durationString returns d as a multiple of its largest exact unit, e.g.
+// 30 * time.Second, or in nanoseconds if the file does not import time
+func (f *Filler) durationString(d time.Duration) string {
+	name, ok := f.importNames["time"]
+	if !ok || name == "." || d == 0 {
+		return strconv.FormatInt(int64(d), 10)
+	}
+	units := []struct {
+		d    time.Duration
+		name string
+	}{
+		{time.Hour, "Hour"},
+		{time.Minute, "Minute"},
+		{time.Second, "Second"},
+		{time.Millisecond, "Millisecond"},
+		{time.Microsecond, "Microsecond"},
+		{time.Nanosecond, "Nanosecond"},
+	}
+	for _, u := range units {
+		if d%u.d != 0 {
+			continue
+		}
+		unit := name + "." + u.name
+		if n := d / u.d; n != 1 {
+			return strconv.FormatInt(int64(n), 10) + " * " + unit
+		}
+		return unit
+	}
+	return strconv.FormatInt(int64(d), 10)
+}
@@ -0,0 +1,108 @@
+package fill
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"path/filepath"
+	"strings"
+)
+
+// ImportNames returns the names the packages imported by f are imported
+// under, by import path. Packages imported without a name take the one
+// they declare, pkg being the package of f, e.g. yaml for gopkg.in/yaml.v3.
+func ImportNames(f *ast.File, pkg *types.Package) map[string]string {
+	declared := make(map[string]string)
+	if pkg != nil {
+		for _, p := range pkg.Imports() {
+			declared[p.Path()] = p.Name()
+		}
+	}
+	imports := make(map[string]string)
+	for _, i := range f.Imports {
+		if i.Name != nil && i.Name.Name != "_" {
+			path := i.Path.Value
+			imports[path[1:len(path)-1]] = i.Name.Name
+		}
+		if i.Name == nil {
+			path := i.Path.Value
+			path = path[1 : len(path)-1]
+			_, name := filepath.Split(path)
+			if name == "." {
+				continue
+			}
+			if d, ok := declared[path]; ok {
+				name = d
+			}
+			imports[path] = name
+		}
+	}
+	return imports
+}
+
+// similarName returns the name of candidates obviously similar to name:
+// equal but for case, or the only one within a small edit distance
+func similarName(name string, candidates []string) string {
+	best, bestDist, unique := "", len(name)/3+1, false
+	for _, c := range candidates {
+		if strings.EqualFold(c, name) {
+			return c
+		}
+		d := editDistance(strings.ToLower(name), strings.ToLower(c))
+		switch {
+		case d < bestDist:
+			best, bestDist, unique = c, d, true
+		case d == bestDist:
+			unique = false
+		}
+	}
+	if !unique || bestDist > 2 {
+		return ""
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// IsZeroConst reports whether v is the zero value of a constant's type
+func IsZeroConst(v constant.Value) bool {
+	switch v.Kind() {
+	case constant.Bool:
+		return !constant.BoolVal(v)
+	case constant.String:
+		return constant.StringVal(v) == ""
+	case constant.Int, constant.Float, constant.Complex:
+		return constant.Sign(v) == 0
+	default:
+		return false
+	}
+}
+
+// hasPrefix reports whether s starts with one of prefixes
+func hasPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
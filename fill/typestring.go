@@ -1,4 +1,4 @@
-package main
+package fill
 
 import (
 	"bytes"
@@ -13,7 +13,7 @@ type typeWriter struct {
 	importNames map[string]string
 }
 
-func typeString(pkg *types.Package, importNames map[string]string, typ types.Type) (string, bool) {
+func TypeString(pkg *types.Package, importNames map[string]string, typ types.Type) (string, bool) {
 	w := typeWriter{
 		buf:         &bytes.Buffer{},
 		pkg:         pkg,
@@ -163,6 +163,16 @@ func (w *typeWriter) writeType(typ types.Type, visited []types.Type) {
 		} else {
 			w.buf.WriteString(t.Obj().Name())
 		}
+		if args := t.TypeArgs(); args.Len() > 0 {
+			w.buf.WriteByte('[')
+			for i := 0; i < args.Len(); i++ {
+				if i > 0 {
+					w.buf.WriteString(", ")
+				}
+				w.writeType(args.At(i), visited)
+			}
+			w.buf.WriteByte(']')
+		}
 
 	default:
 		// For externally defined implementations of Type.
@@ -2,7 +2,10 @@ module github.com/CaiJinKen/fillstruct
 
 go 1.21.0
 
-require golang.org/x/tools v0.15.0
+require (
+	golang.org/x/tools v0.15.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	golang.org/x/mod v0.14.0 // indirect
@@ -2,19 +2,27 @@ package main
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"go/ast"
 	"go/format"
+	"go/parser"
 	"go/printer"
-	"go/token"
 	"go/types"
 	"io"
+	"log"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
+	"github.com/CaiJinKen/fillstruct/fill"
 	"golang.org/x/tools/go/packages"
 )
 
+// maxCandidates is the number of nearby literals listed when nothing matched
+const maxCandidates = 3
+
 // handler include file check, travel ast file, fill struct with type-zero value, write back
 type handler struct {
 	// param
@@ -22,12 +30,21 @@ type handler struct {
 	line     int
 	// internal use
 	pkgs        []*packages.Package
+	loadErr     error
 	pkg         *packages.Package
 	f           *ast.File
 	importNames map[string]string // import path -> import name
 
 	resultNode  ast.Node
 	isValueSpec bool
+	err         error  // first error met while filling
+	src         []byte // formatted source of the filled file
+	unknown     []fill.UnknownField
+	merges      []lineRange // lines of the nodes removed by -compact
+	opts        fill.Options
+	configPath  string   // configuration file applied, if any
+	settings    settings // settings of the configuration file for the file
+	ifaceFields []fill.InterfaceField
 }
 
 func newHandler(filepath string, line int) *handler {
@@ -49,23 +66,29 @@ func (h *handler) preCheck() (err error) {
 	}
 	h.filepath = path
 
-	pkgs, err := packages.Load(&packages.Config{
-		Mode:  packages.LoadAllSyntax,
-		Tests: true,
-		Dir:   filepath.Dir(path),
-		Fset:  token.NewFileSet(),
-		Env:   os.Environ(),
-	})
-	if err != nil {
+	if err = h.configure(); err != nil {
 		return
 	}
-	h.pkgs = pkgs
+	if h.opts, err = h.fillOptions(); err != nil {
+		return
+	}
+
+	// a failed load is not fatal: the file may still be type checked on its own
+	h.pkgs, h.loadErr = packages.Load(h.loadConfig(), "file="+path)
 	return
 }
 
-// travel packages to find the assigned file to hand ast.Node
-func (h *handler) travel() (err error) {
+// findFile looks for the assigned file in the loaded packages. A file may
+// belong to several variants of a package, the best suited one is chosen
+// by variantRank.
+func (h *handler) findFile() bool {
+	isTest := strings.HasSuffix(h.filepath, "_test.go")
+	best := -1
 	for _, p := range h.pkgs {
+		rank := variantRank(p, isTest)
+		if rank <= best {
+			continue
+		}
 		for _, af := range p.Syntax {
 			if file := p.Fset.File(af.Pos()); file.Name() != h.filepath {
 				continue
@@ -73,18 +96,23 @@ func (h *handler) travel() (err error) {
 
 			h.f = af
 			h.pkg = p
-			goto do
+			best = rank
+			break
 		}
 	}
+	return h.f != nil
+}
 
-do:
-	h.pkgs = nil // release memory
-
-	if h.f == nil || h.pkg == nil {
-		return fmt.Errorf("could not find file %q", h.filepath)
+// travel packages to find the assigned file to hand ast.Node
+func (h *handler) travel() (err error) {
+	if !h.findFile() {
+		if err = h.loadAdHoc(); err != nil {
+			return
+		}
 	}
+	h.pkgs = nil // release memory
 
-	h.importNames = buildImportNameMap(h.f)
+	h.importNames = fill.ImportNames(h.f, h.pkg.Types)
 
 	ast.Inspect(h.f, func(n ast.Node) bool {
 		if !h.checkPos(n) {
@@ -102,9 +130,122 @@ do:
 		}
 	})
 
+	if err = h.checkErrors(); err != nil {
+		return
+	}
+	if h.err != nil {
+		return h.err
+	}
+	h.reportUnknown()
+	if h.resultNode == nil {
+		return h.notFound()
+	}
+	h.mergeLines(h.f)
 	return
 }
 
+// checkErrors prints the errors of the loaded package. Filling a package
+// with errors is refused unless -force is given, in which case only the
+// fields whose types could be resolved are filled.
+func (h *handler) checkErrors() error {
+	var typeErrors bool
+	for _, e := range h.pkg.Errors {
+		typeErrors = typeErrors || e.Kind == packages.TypeError
+	}
+	unknown := make(map[string]bool, len(h.unknown))
+	for _, u := range h.unknown {
+		unknown[h.pkg.Fset.Position(u.Pos).String()] = true
+	}
+	var errs []packages.Error
+	for _, e := range h.pkg.Errors {
+		// unknown keys of the filled literal are reported on their own
+		if unknown[e.Pos] {
+			continue
+		}
+		// the go command's compile errors, reported without a position,
+		// repeat the type errors
+		if typeErrors && e.Kind != packages.TypeError && (e.Pos == "" || e.Pos == "-") {
+			continue
+		}
+		errs = append(errs, e)
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+
+	for _, e := range errs {
+		log.Println(e)
+	}
+	if *force {
+		return nil
+	}
+	return &exitError{
+		code: exitTypeError,
+		err:  fmt.Errorf("package %s has %d error(s), use -force to fill anyway", h.pkg.Name, len(errs)),
+	}
+}
+
+// reportUnknown reports the keys of the filled literals that are not
+// fields of their type and what was done with them
+func (h *handler) reportUnknown() {
+	for _, u := range h.unknown {
+		typeName, _ := fill.TypeString(h.pkg.Types, h.importNames, u.Type)
+		msg := fmt.Sprintf("%s: unknown field %s in %s", h.pkg.Fset.Position(u.Pos), u.Name, typeName)
+		switch {
+		case u.Renamed:
+			msg += ", renamed to " + u.Similar
+		case u.Pruned:
+			msg += ", removed"
+		case u.Similar != "":
+			msg += fmt.Sprintf(", kept (did you mean %s? use -rename-unknown)", u.Similar)
+		default:
+			msg += ", kept"
+		}
+		log.Println(msg)
+	}
+}
+
+// notFound reports that no struct literal ends at the assigned line,
+// listing the nearest composite literals of the file
+func (h *handler) notFound() error {
+	type candidate struct {
+		lit  *ast.CompositeLit
+		dist int
+	}
+	var candidates []candidate
+	ast.Inspect(h.f, func(n ast.Node) bool {
+		if lit, ok := n.(*ast.CompositeLit); ok {
+			dist := h.pkg.Fset.Position(lit.Rbrace).Line - h.line
+			if dist < 0 {
+				dist = -dist
+			}
+			candidates = append(candidates, candidate{lit: lit, dist: dist})
+		}
+		return true
+	})
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].dist < candidates[j].dist
+	})
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "no struct literal found at %s:%d", h.filepath, h.line)
+	for i, c := range candidates {
+		if i == maxCandidates {
+			break
+		}
+		if i == 0 {
+			b.WriteString(", nearest composite literals:")
+		}
+		typeName := "invalid type"
+		if t := h.pkg.TypesInfo.TypeOf(c.lit); t != nil {
+			typeName, _ = fill.TypeString(h.pkg.Types, h.importNames, t)
+		}
+		pos := h.pkg.Fset.Position(c.lit.Rbrace)
+		fmt.Fprintf(&b, "\n\t%s:%d:%d: %s", filepath.Base(pos.Filename), pos.Line, pos.Column, typeName)
+	}
+	return &exitError{code: exitNotFound, err: errors.New(b.String())}
+}
+
 // handValueSpec hand ast.ValueSpec
 func (h *handler) handValueSpec(node *ast.ValueSpec) (isTarget bool) {
 	if !h.checkPos(node) {
@@ -264,28 +405,155 @@ func (h *handler) checkPos(node ast.Node) (ok bool) {
 	return !(startLine > h.line || endLine < h.line)
 }
 
-// hintLine whether the position of the node equals to assigned line
+// hintLine whether the position of the node, or of a literal nested in it,
+// equals to assigned line. Nested literals are filled through the outermost
+// one so that the values around them are kept.
 func (h *handler) hintLine(node *ast.CompositeLit) (yes bool) {
-	return h.pkg.Fset.Position(node.Rbrace).Line == h.line
+	ast.Inspect(node, func(n ast.Node) bool {
+		if lit, ok := n.(*ast.CompositeLit); ok && h.pkg.Fset.Position(lit.Rbrace).Line == h.line {
+			yes = true
+		}
+		return !yes
+	})
+	return
 }
 
-// fillCompositeList gen assigned zero value
+// fillCompositeList gen assigned zero value, or rewrites the literal
+// with -compact, -keyed or -positional
 func (h *handler) fillCompositeList(node *ast.CompositeLit) (result ast.Expr) {
-	var info litInfo
-	var prev types.Type
-	var ok bool
-	info.name, _ = h.pkg.TypesInfo.Types[node].Type.(*types.Named)
-	info.typ, ok = h.pkg.TypesInfo.Types[node].Type.Underlying().(*types.Struct)
-	if !ok {
-		prev = h.pkg.TypesInfo.Types[node].Type.Underlying()
+	result = node
+	typ := h.pkg.TypesInfo.TypeOf(node)
+	if typ == nil || typ == types.Typ[types.Invalid] {
+		h.setErr(exitTypeError, node, "could not determine the type of the literal")
+		return
+	}
+
+	// the rewrites apply to the struct literals inside slice, array
+	// and map literals as well
+	switch {
+	case *compactLit:
+		h.compact(node)
+		return
+	case *keyed:
+		h.toKeyed(node)
+		return
+	case *positional:
+		h.toPositional(node)
+		return
+	}
+	if _, ok := typ.Underlying().(*types.Struct); !ok {
+		h.setErr(exitUnsupported, node, "cannot fill a literal of type %s", typ)
 		return
 	}
-	info.hideType = hideType(prev)
-	result, _ = zeroValue(h.pkg.Types, h.importNames, node, info)
+
+	pos := h.pkg.Fset.Position(node.Pos())
+	h.dropUnknownComments(node)
+	f := fill.New(h.pkg.Types, h.importNames, h.opts)
+	f.SetSyntax(h.pkg.Fset, h.pkg.Syntax)
+	if h.opts.UseScope {
+		f.SetScope(node.Pos())
+	}
+	result = f.Fill(node, typ)
+	h.unknown = append(h.unknown, f.Unknown()...)
+	h.ifaceFields = append(h.ifaceFields, f.InterfaceFields()...)
+	for _, c := range f.Cycles() {
+		typeName, _ := fill.TypeString(h.pkg.Types, h.importNames, c.Type)
+		log.Printf("%s: %s left nil to cut the cycle through %s", pos, c.Path, typeName)
+	}
+	for _, d := range f.BadDefaults() {
+		log.Printf("%s: invalid default %q of field %s: %v", h.pkg.Fset.Position(d.Field.Pos()), d.Value, d.Field.Name(), d.Err)
+	}
 
 	return
 }
 
+// dropUnknownComments removes the comments marking unknown keys in node,
+// left by a previous run. They are added again to the keys still unknown.
+func (h *handler) dropUnknownComments(node ast.Node) {
+	comments := h.f.Comments[:0]
+	for _, c := range h.f.Comments {
+		if c.Pos() >= node.Pos() && c.End() <= node.End() && len(c.List) == 1 && c.List[0].Text == unknownComment {
+			continue
+		}
+		comments = append(comments, c)
+	}
+	h.f.Comments = comments
+}
+
+// fillOptions returns the fill options given on the command line
+func (h *handler) fillOptions() (opts fill.Options, err error) {
+	opts = fill.Options{
+		PruneUnknown:      *pruneUnknown,
+		RenameUnknown:     *renameUnknown,
+		Depth:             *depth,
+		PathDepth:         h.settings.PathDepth,
+		EnumFirst:         *enumFirst,
+		TagDefaults:       *tagDefaults,
+		Values:            *values,
+		Seed:              *seed,
+		Implementations:   *implMode,
+		UseImplementation: h.settings.UseImplementation,
+		CtorDefaults:      *ctorDefaults,
+		UseScope:          *useScope,
+		Format:            h.settings.Format,
+	}
+
+	if opts.Filter, err = fill.NewFieldFilter(include, exclude, *exportedOnly, withTag, skipIgnored); err != nil {
+		return
+	}
+
+	switch opts.Implementations {
+	case "", fill.ImplementationsFill, fill.ImplementationsList:
+	default:
+		return opts, fmt.Errorf("invalid -implementations %q, want fill or list", opts.Implementations)
+	}
+
+	switch opts.Values {
+	case fill.ValuesZero, fill.ValuesRandom:
+	case fill.ValuesExample:
+		if opts.Examples, err = fill.NewExampleRules(append(h.settings.Examples, fill.DefaultExamples...)); err != nil {
+			return
+		}
+	default:
+		return opts, fmt.Errorf("invalid -values %q, want zero, example or random", opts.Values)
+	}
+
+	if len(h.settings.Types) > 0 {
+		opts.TypeFilters = make(map[string]*fill.FieldFilter, len(h.settings.Types))
+	}
+	for typ, ts := range h.settings.Types {
+		if opts.TypeFilters[typ], err = fill.NewFieldFilter(ts.Include, ts.Exclude, ts.ExportedOnly, ts.WithTag, ts.SkipIgnored); err != nil {
+			return
+		}
+	}
+
+	opts.Presets = make(map[string]fill.Preset, len(fill.DefaultPresets)+len(h.settings.Presets)+len(presetFlags))
+	for typ, p := range fill.DefaultPresets {
+		opts.Presets[typ] = p
+	}
+	given := make([]string, 0, len(h.settings.Presets)+len(presetFlags))
+	for typ, value := range h.settings.Presets {
+		given = append(given, typ+"="+value)
+	}
+	for _, s := range append(given, presetFlags...) {
+		typ, p, err := fill.ParsePreset(s)
+		if err != nil {
+			return opts, err
+		}
+		opts.Presets[typ] = p
+	}
+	return
+}
+
+// setErr records the first error met while filling
+func (h *handler) setErr(code int, node ast.Node, format string, args ...interface{}) {
+	if h.err != nil {
+		return
+	}
+	pos := h.pkg.Fset.Position(node.Pos())
+	h.err = &exitError{code: code, err: fmt.Errorf("%s: %s", pos, fmt.Sprintf(format, args...))}
+}
+
 // writeBack write back to the source file
 func (h *handler) writeBack() (err error) {
 	var writers []io.Writer
@@ -298,7 +566,7 @@ func (h *handler) writeBack() (err error) {
 	}
 
 	if *writeback {
-		f, err := os.OpenFile(h.filepath, os.O_RDWR, 0o66)
+		f, err := os.OpenFile(h.filepath, os.O_RDWR|os.O_TRUNC, 0o66)
 		if err != nil {
 			return err
 		}
@@ -310,23 +578,33 @@ func (h *handler) writeBack() (err error) {
 	if len(writers) == 0 {
 		return
 	}
-	var buf bytes.Buffer
-	printer.Fprint(&buf, h.pkg.Fset, h.f)
-	data, err := format.Source(buf.Bytes())
-	if err != nil {
-		return
-	}
 
 	w := io.MultiWriter(writers...)
 
-	_, err = w.Write(data)
+	_, err = w.Write(h.src)
 
 	return
 }
 
+// printLine prints the filled node as written to the file, up to the end
+// of its last line so that the comments marking unknown keys are kept
 func (h *handler) printLine() {
 	var buf bytes.Buffer
-	printer.Fprint(&buf, h.pkg.Fset, h.resultNode)
+	if af, err := parser.ParseFile(h.pkg.Fset, h.filepath, h.src, parser.ParseComments); err == nil {
+		if n := locate(h.pkg.Fset, af, h.resultNode); n != nil {
+			file := h.pkg.Fset.File(af.Pos())
+			start, end := file.Offset(n.Pos()), file.Offset(n.End())
+			if i := bytes.IndexByte(h.src[end:], '\n'); i >= 0 {
+				end += i
+			} else {
+				end = len(h.src)
+			}
+			buf.Write(h.src[start:end])
+		}
+	}
+	if buf.Len() == 0 {
+		printer.Fprint(&buf, h.pkg.Fset, h.resultNode)
+	}
 	data, err := format.Source(buf.Bytes())
 	if err != nil {
 		return
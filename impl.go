@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/CaiJinKen/fillstruct/fill"
+)
+
+// printImplementations writes the implementations found for the interface
+// fields of the literal, one field per line: its path, its type and the
+// literals of its implementations, separated by tabs
+func (h *handler) printImplementations(w io.Writer) {
+	sort.SliceStable(h.ifaceFields, func(i, j int) bool {
+		return h.ifaceFields[i].Path < h.ifaceFields[j].Path
+	})
+	for _, field := range h.ifaceFields {
+		typeName, _ := fill.TypeString(h.pkg.Types, h.importNames, field.Interface)
+		line := []string{field.Path, typeName}
+		for _, impl := range field.Implementations {
+			name, _ := fill.TypeString(h.pkg.Types, h.importNames, impl.Named)
+			if impl.Pointer {
+				name = "&" + name
+			}
+			line = append(line, name+"{}")
+		}
+		fmt.Fprintln(w, strings.Join(line, "\t"))
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+const loadMode = packages.NeedName |
+	packages.NeedFiles |
+	packages.NeedCompiledGoFiles |
+	packages.NeedTypes |
+	packages.NeedTypesInfo |
+	packages.NeedTypesSizes |
+	packages.NeedSyntax
+
+// loadConfig returns the packages.Config used to load the target file.
+// Full syntax and type info are only needed for the package containing
+// the file, dependencies are type checked from export data.
+func (h *handler) loadConfig() *packages.Config {
+	cfg := &packages.Config{
+		Mode:  loadMode,
+		Tests: strings.HasSuffix(h.filepath, "_test.go"),
+		Dir:   filepath.Dir(h.filepath),
+		Fset:  token.NewFileSet(),
+		Env:   os.Environ(),
+	}
+	if *tags != "" {
+		cfg.BuildFlags = append(cfg.BuildFlags, "-tags="+*tags)
+	}
+	if *goos != "" {
+		cfg.Env = append(cfg.Env, "GOOS="+*goos)
+	}
+	if *goarch != "" {
+		cfg.Env = append(cfg.Env, "GOARCH="+*goarch)
+	}
+	return cfg
+}
+
+// loadAdHoc type checks the assigned file on its own. It is used when the
+// file is not part of any loaded package, e.g. it is excluded by build
+// constraints or lives outside of a module.
+func (h *handler) loadAdHoc() (err error) {
+	cfg := h.loadConfig()
+	af, err := parser.ParseFile(cfg.Fset, h.filepath, nil, parser.ParseComments)
+	if err != nil {
+		if h.loadErr != nil {
+			err = fmt.Errorf("%v; %v", h.loadErr, err)
+		}
+		return fmt.Errorf("could not find file %q: %v", h.filepath, err)
+	}
+
+	// dependencies are loaded with the same build flags and environment
+	var paths []string
+	for _, spec := range af.Imports {
+		if path, _ := strconv.Unquote(spec.Path.Value); path != "" && path != "C" && path != "unsafe" {
+			paths = append(paths, path)
+		}
+	}
+	deps := make(map[string]*types.Package)
+	if len(paths) > 0 {
+		cfg.Mode = packages.NeedName | packages.NeedTypes
+		cfg.Tests = false
+		pkgs, err := packages.Load(cfg, paths...)
+		if err != nil {
+			return err
+		}
+		for _, p := range pkgs {
+			if p.Types != nil {
+				deps[p.PkgPath] = p.Types
+			}
+		}
+	}
+
+	pkg := &packages.Package{
+		ID:              "command-line-arguments",
+		Name:            af.Name.Name,
+		PkgPath:         "command-line-arguments",
+		GoFiles:         []string{h.filepath},
+		CompiledGoFiles: []string{h.filepath},
+		Fset:            cfg.Fset,
+		Syntax:          []*ast.File{af},
+		TypesInfo: &types.Info{
+			Types:      make(map[ast.Expr]types.TypeAndValue),
+			Defs:       make(map[*ast.Ident]types.Object),
+			Uses:       make(map[*ast.Ident]types.Object),
+			Implicits:  make(map[ast.Node]types.Object),
+			Scopes:     make(map[ast.Node]*types.Scope),
+			Selections: make(map[*ast.SelectorExpr]*types.Selection),
+		},
+	}
+	arch := runtime.GOARCH
+	if *goarch != "" {
+		arch = *goarch
+	}
+	conf := types.Config{
+		Importer: importerFunc(func(path string) (*types.Package, error) {
+			if path == "unsafe" {
+				return types.Unsafe, nil
+			}
+			if p, ok := deps[path]; ok {
+				return p, nil
+			}
+			return nil, fmt.Errorf("could not import %q", path)
+		}),
+		Sizes: types.SizesFor("gc", arch),
+		// keep going, the literal to fill may still be well typed
+		Error: func(err error) {
+			e := packages.Error{Msg: err.Error(), Kind: packages.TypeError}
+			if te, ok := err.(types.Error); ok {
+				e.Pos = te.Fset.Position(te.Pos).String()
+				e.Msg = te.Msg
+			}
+			pkg.Errors = append(pkg.Errors, e)
+		},
+	}
+	pkg.Types, _ = conf.Check(pkg.PkgPath, cfg.Fset, pkg.Syntax, pkg.TypesInfo)
+	pkg.TypesSizes = conf.Sizes
+
+	h.f = af
+	h.pkg = pkg
+	return nil
+}
+
+// variantRank ranks the variants returned for a package when tests are
+// loaded: the plain package "p", its test variant "p [p.test]", the
+// external test package "p_test [p.test]" and the test main "p.test".
+// Test files are best served by the test variants, which see unexported
+// helpers and test-only declarations, other files by the plain package.
+func variantRank(p *packages.Package, isTest bool) int {
+	switch {
+	case strings.HasSuffix(p.ID, ".test") && !strings.Contains(p.ID, " "):
+		// test main, generated code only
+		return 0
+	case strings.Contains(p.ID, " ["):
+		if isTest {
+			return 2
+		}
+		return 1
+	default:
+		if isTest {
+			return 1
+		}
+		return 2
+	}
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) { return f(path) }
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// benchFile returns the file the load benchmarks fill, taken from
+// FILLSTRUCT_BENCH_FILE to measure a large module, else handler.go
+func benchFile(b *testing.B) string {
+	file := os.Getenv("FILLSTRUCT_BENCH_FILE")
+	if file == "" {
+		file = "handler.go"
+	}
+	file, err := filepath.Abs(file)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return file
+}
+
+// BenchmarkLoadAllSyntax loads the package of the file the way fillstruct
+// used to, with full syntax for every dependency and all test variants
+func BenchmarkLoadAllSyntax(b *testing.B) {
+	file := benchFile(b)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, err := packages.Load(&packages.Config{
+			Mode:  packages.LoadAllSyntax,
+			Tests: true,
+			Dir:   filepath.Dir(file),
+			Fset:  token.NewFileSet(),
+			Env:   os.Environ(),
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkLoadFile loads the package of the file with the file= query
+// and the narrower mode, dependencies coming from export data
+func BenchmarkLoadFile(b *testing.B) {
+	h := newHandler(benchFile(b), 0)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := packages.Load(h.loadConfig(), "file="+h.filepath); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
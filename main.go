@@ -1,21 +1,59 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"log"
 	"os"
 	"strings"
+
+	"github.com/CaiJinKen/fillstruct/fill"
+)
+
+var (
+	filename      = flag.String("file", "", "filename")
+	line          = flag.Int("line", 0, "line number of the struct literal")
+	writeback     = flag.Bool("writeback", false, "writeback to the file")
+	stdOut        = flag.Bool("std-out", true, "print info into stdout")
+	onlyChanged   = flag.Bool("only-changed", false, "just print changed line, false will print all info")
+	version       = flag.String("version", "", "print fillstruct version")
+	tags          = flag.String("tags", "", "comma-separated list of build tags to apply when loading the file")
+	goos          = flag.String("goos", "", "GOOS to load the file with, defaults to the environment")
+	exportedOnly  = flag.Bool("exported-only", false, "fill exported fields only, even of local types")
+	depth         = flag.Int("depth", 0, "levels of fields to fill, deeper pointers, slices and maps are nil and structs empty; 0 fills all")
+	enumFirst     = flag.Bool("enum-first", false, "fill named basic types with their first declared constant rather than the zero-valued one")
+	tagDefaults   = flag.Bool("tag-defaults", false, "fill fields with the value of their default or envDefault tag")
+	ctorDefaults  = flag.Bool("constructor-defaults", false, "fill fields with the values the DefaultT or NewT function of their struct type sets, where it returns a literal")
+	useScope      = flag.Bool("use-scope", false, "fill fields with the variables in scope assignable to them, preferring similar names")
+	values        = flag.String("values", "zero", "values to fill fields with: zero, example for plausible ones after the field names, or random")
+	seed          = flag.Int64("seed", 1, "seed of the values made up by -values=random")
+	implMode      = flag.String("implementations", "", "fill interface fields with a struct implementing them: fill with the configured or only one found in the package and the non-standard packages the file imports, list to print them instead")
+	goarch        = flag.String("goarch", "", "GOARCH to load the file with, defaults to the environment")
+	force         = flag.Bool("force", false, "fill even if the package has errors, fields of unresolved types are skipped")
+	partial       = flag.Bool("partial", false, "drop the filled fields that do not type check instead of aborting")
+	pruneUnknown  = flag.Bool("prune-unknown", false, "remove keys that are not fields of the literal's type")
+	renameUnknown = flag.Bool("rename-unknown", false, "rename unknown keys to an obviously similar missing field")
+	compactLit    = flag.Bool("compact", false, "remove the keys whose value is the zero value instead of filling the literal")
+	keyed         = flag.Bool("keyed", false, "convert positional struct literals to keyed ones instead of filling the literal")
+	positional    = flag.Bool("positional", false, "convert keyed literals of small local types to positional ones instead of filling the literal")
 )
 
 var (
-	filename    = flag.String("file", "", "filename")
-	line        = flag.Int("line", 0, "line number of the struct literal")
-	writeback   = flag.Bool("writeback", false, "writeback to the file")
-	stdOut      = flag.Bool("std-out", true, "print info into stdout")
-	onlyChanged = flag.Bool("only-changed", false, "just print changed line, false will print all info")
-	version     = flag.String("version", "", "print fillstruct version")
+	include     stringList
+	exclude     stringList
+	withTag     stringList
+	skipIgnored stringList
+	presetFlags stringList
 )
 
+func init() {
+	flag.Var(&include, "include", "fill only the fields whose path, e.g. Addr.City, matches the regexp; may be repeated")
+	flag.Var(&exclude, "exclude", "do not fill the fields whose path matches the regexp; may be repeated")
+	flag.Var(&withTag, "with-tag", "fill only the fields having a tag with this key, e.g. json; may be repeated")
+	flag.Var(&skipIgnored, "skip-ignored", "do not fill the fields whose tag value for this key is \"-\", e.g. json; may be repeated")
+	flag.Var(&presetFlags, "preset", "fill a type, e.g. time.Time or *math/big.Int, with omit, zero or an expression, e.g. time.Time=time.Now(); may be repeated")
+}
+
 func main() {
 	log.SetFlags(0)
 	log.SetPrefix("fillstruct: ")
@@ -25,21 +63,54 @@ func main() {
 		os.Exit(0)
 	}
 
-	flag.Parse()
+	// fillstruct config print -file <filename>
+	if args := os.Args[1:]; len(args) >= 2 && args[0] == "config" && args[1] == "print" {
+		parseFlags(args[2:])
+		if *filename == "" {
+			flag.PrintDefaults()
+			os.Exit(exitFailure)
+		}
+		if err := newHandler(*filename, 0).printConfig(os.Stdout); err != nil {
+			fatal(err)
+		}
+		return
+	}
+
+	parseFlags(os.Args[1:])
 
 	if *line == 0 || *filename == "" {
 		flag.PrintDefaults()
-		os.Exit(1)
+		os.Exit(exitFailure)
 	}
 
 	h := newHandler(*filename, *line)
 	if err := h.preCheck(); err != nil {
-		log.Fatal(err)
+		fatal(err)
 	}
 	if err := h.travel(); err != nil {
-		log.Fatal(err)
+		fatal(err)
+	}
+	if *implMode == fill.ImplementationsList {
+		h.printImplementations(os.Stdout)
+		return
+	}
+	if err := h.verify(); err != nil {
+		fatal(err)
 	}
 	if err := h.writeBack(); err != nil {
-		log.Fatal(err)
+		fatal(&exitError{code: exitWriteFailed, err: err})
+	}
+}
+
+// parseFlags parses the command line flags. A bad flag exits with
+// exitFailure like any other invalid usage, rather than the 2 of
+// flag.ExitOnError that stands for a literal not found.
+func parseFlags(args []string) {
+	flag.CommandLine.Init(os.Args[0], flag.ContinueOnError)
+	if err := flag.CommandLine.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		os.Exit(exitFailure)
 	}
 }
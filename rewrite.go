@@ -0,0 +1,109 @@
+package main
+
+import (
+	"go/ast"
+	"go/types"
+	"log"
+)
+
+// maxPositionalFields is the number of fields up to which -positional
+// considers a struct small enough to be written without keys
+const maxPositionalFields = 4
+
+// structOf returns the struct type of lit, or nil if it is not a struct literal
+func (h *handler) structOf(lit *ast.CompositeLit) (*types.Named, *types.Struct) {
+	typ := h.pkg.TypesInfo.TypeOf(lit)
+	if typ == nil {
+		return nil, nil
+	}
+	if p, ok := typ.(*types.Pointer); ok {
+		// &T{...} elided in a slice, array or map literal
+		typ = p.Elem()
+	}
+	named, _ := typ.(*types.Named)
+	st, _ := typ.Underlying().(*types.Struct)
+	return named, st
+}
+
+// toKeyed rewrites the positional struct literals in node, node included,
+// into keyed ones, using the field order of their types. The keys take the
+// position of their values, so comments stay where they are. The filler is
+// not used: it adds the missing fields, and fixExprPos would move every
+// value to a made up position, away from its comments.
+func (h *handler) toKeyed(node *ast.CompositeLit) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		lit, ok := n.(*ast.CompositeLit)
+		if !ok || len(lit.Elts) == 0 {
+			return true
+		}
+		_, st := h.structOf(lit)
+		if st == nil {
+			return true
+		}
+		if _, ok := lit.Elts[0].(*ast.KeyValueExpr); ok {
+			return true
+		}
+		for i, e := range lit.Elts {
+			if i >= st.NumFields() {
+				break
+			}
+			lit.Elts[i] = &ast.KeyValueExpr{
+				Key:   &ast.Ident{Name: st.Field(i).Name(), NamePos: e.Pos()},
+				Colon: e.Pos(),
+				Value: e,
+			}
+		}
+		return true
+	})
+}
+
+// toPositional rewrites the keyed struct literals in node, node included,
+// into positional ones where it is safe: the type is declared in the
+// package, has at most maxPositionalFields fields, and every field is
+// given in declaration order, so that no value has to be made up and
+// the values are evaluated in the same order.
+func (h *handler) toPositional(node *ast.CompositeLit) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		lit, ok := n.(*ast.CompositeLit)
+		if !ok {
+			return true
+		}
+		named, st := h.structOf(lit)
+		if st == nil {
+			return true
+		}
+		if reason := h.positionalUnsafe(lit, named, st); reason != "" {
+			if lit == node {
+				log.Printf("%s: kept keyed: %s", h.pkg.Fset.Position(lit.Pos()), reason)
+			}
+			return true
+		}
+		for i, e := range lit.Elts {
+			lit.Elts[i] = e.(*ast.KeyValueExpr).Value
+		}
+		return true
+	})
+}
+
+// positionalUnsafe returns why lit cannot be written without keys,
+// or an empty string if it can
+func (h *handler) positionalUnsafe(lit *ast.CompositeLit, named *types.Named, st *types.Struct) string {
+	switch {
+	case named == nil || named.Obj().Pkg() != h.pkg.Types:
+		return "the type is not declared in this package"
+	case st.NumFields() > maxPositionalFields:
+		return "the type has more than a few fields"
+	case len(lit.Elts) != st.NumFields():
+		return "not every field is given"
+	}
+	for i, e := range lit.Elts {
+		kv, ok := e.(*ast.KeyValueExpr)
+		if !ok {
+			return "the literal is already positional"
+		}
+		if key, ok := kv.Key.(*ast.Ident); !ok || key.Name != st.Field(i).Name() {
+			return "the fields are not given in declaration order"
+		}
+	}
+	return ""
+}
@@ -1,9 +1,8 @@
 package main
 
 import (
-	"go/ast"
-	"go/types"
 	"path/filepath"
+	"strings"
 )
 
 // absPath returns the full path of the filename
@@ -15,37 +14,12 @@ func absPath(filename string) (string, error) {
 	return filepath.Abs(eval)
 }
 
-// hideType returns true when t is array || map || slice
-func hideType(t types.Type) bool {
-	switch t.(type) {
-	case *types.Array:
-		return true
-	case *types.Map:
-		return true
-	case *types.Slice:
-		return true
-	default:
-		return false
-	}
-}
+// stringList is a flag.Value collecting the values of a repeated flag
+type stringList []string
 
-// buildImportNameMap get all imported packages in file
-func buildImportNameMap(f *ast.File) map[string]string {
-	imports := make(map[string]string)
-	for _, i := range f.Imports {
-		if i.Name != nil && i.Name.Name != "_" {
-			path := i.Path.Value
-			imports[path[1:len(path)-1]] = i.Name.Name
-		}
-		if i.Name == nil {
-			path := i.Path.Value
-			path = path[1 : len(path)-1]
-			_, name := filepath.Split(path)
-			if name == "." {
-				continue
-			}
-			imports[path] = name
-		}
-	}
-	return imports
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
 }
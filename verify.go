@@ -0,0 +1,278 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"go/types"
+	"reflect"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/packages"
+)
+
+// verify type checks the filled file against the already loaded package
+// before anything is written. Errors that were not present before the fill
+// abort the write, with -partial the fields causing them are dropped instead.
+func (h *handler) verify() (err error) {
+	src, err := h.source(h.f)
+	if err == nil {
+		src, err = h.markUnknown(src)
+	}
+	if err != nil {
+		return &exitError{code: exitTypeError, err: fmt.Errorf("the filled file is not valid Go: %v", err)}
+	}
+
+	known := make(map[string]int)
+	for _, e := range h.pkg.Errors {
+		if e.Kind == packages.TypeError {
+			known[e.Msg]++
+		}
+	}
+
+	dropped := false
+	for {
+		af, errs, err := h.check(src)
+		if err != nil {
+			return &exitError{code: exitTypeError, err: fmt.Errorf("the filled file is not valid Go: %v", err)}
+		}
+		errs = newErrors(errs, known)
+		if len(errs) == 0 {
+			if dropped {
+				h.f = af
+				h.resultNode = locate(h.pkg.Fset, af, h.resultNode)
+			}
+			h.src = src
+			return nil
+		}
+
+		if !*partial || !h.dropFields(af, errs) {
+			var b strings.Builder
+			b.WriteString("the filled file does not type check, nothing was written:")
+			for _, e := range errs {
+				fmt.Fprintf(&b, "\n\t%s", e)
+			}
+			return &exitError{code: exitTypeError, err: errors.New(b.String())}
+		}
+		dropped = true
+
+		if src, err = h.source(af); err != nil {
+			return &exitError{code: exitTypeError, err: fmt.Errorf("the filled file is not valid Go: %v", err)}
+		}
+	}
+}
+
+// source prints and formats af
+func (h *handler) source(af *ast.File) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, h.pkg.Fset, af); err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
+
+// unknownComment marks the keys that are not fields of the literal's type
+const unknownComment = "// fillstruct: unknown field"
+
+// markUnknown marks the unknown keys kept in the filled literals with a
+// comment. The filled literals carry made up positions, so the comments
+// are added to the printed and parsed again file instead.
+func (h *handler) markUnknown(src []byte) ([]byte, error) {
+	kept := make(map[*ast.KeyValueExpr]bool)
+	for _, u := range h.unknown {
+		if !u.Renamed && !u.Pruned {
+			kept[u.KV] = true
+		}
+	}
+	if len(kept) == 0 {
+		return src, nil
+	}
+
+	af, err := parser.ParseFile(h.pkg.Fset, h.filepath, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	// the parsed file has the same shape as the filled one
+	before, after := keyValues(h.resultNode), keyValues(locate(h.pkg.Fset, af, h.resultNode))
+	if len(before) != len(after) {
+		return src, nil
+	}
+	file := h.pkg.Fset.File(af.Pos())
+	for i, kv := range before {
+		if !kept[kv] {
+			continue
+		}
+		pos := after[i].End()
+		if off := file.Offset(pos); off < len(src) && src[off] == ',' {
+			pos++
+		}
+		af.Comments = append(af.Comments, &ast.CommentGroup{
+			List: []*ast.Comment{{Slash: pos, Text: unknownComment}},
+		})
+	}
+	sort.Slice(af.Comments, func(i, j int) bool {
+		return af.Comments[i].Pos() < af.Comments[j].Pos()
+	})
+	return h.source(af)
+}
+
+// keyValues returns the key-value pairs in node in depth-first order
+func keyValues(node ast.Node) (result []*ast.KeyValueExpr) {
+	if node == nil {
+		return
+	}
+	ast.Inspect(node, func(n ast.Node) bool {
+		if kv, ok := n.(*ast.KeyValueExpr); ok {
+			result = append(result, kv)
+		}
+		return true
+	})
+	return
+}
+
+// check parses src as the assigned file and type checks it together
+// with the other files of the package. Imports are resolved from the
+// already loaded package.
+func (h *handler) check(src []byte) (*ast.File, []types.Error, error) {
+	af, err := parser.ParseFile(h.pkg.Fset, h.filepath, src, parser.ParseComments)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	files := make([]*ast.File, 0, len(h.pkg.Syntax))
+	for _, f := range h.pkg.Syntax {
+		if f == h.f {
+			f = af
+		}
+		files = append(files, f)
+	}
+	if len(files) == 0 {
+		files = append(files, af)
+	}
+
+	imports := make(map[string]*types.Package)
+	if h.pkg.Types != nil {
+		for _, p := range h.pkg.Types.Imports() {
+			imports[p.Path()] = p
+		}
+	}
+
+	var errs []types.Error
+	conf := types.Config{
+		Importer: importerFunc(func(path string) (*types.Package, error) {
+			if path == "unsafe" {
+				return types.Unsafe, nil
+			}
+			if p, ok := imports[path]; ok {
+				return p, nil
+			}
+			return nil, fmt.Errorf("could not import %q", path)
+		}),
+		Sizes: h.pkg.TypesSizes,
+		Error: func(err error) {
+			if te, ok := err.(types.Error); ok {
+				errs = append(errs, te)
+			}
+		},
+	}
+	conf.Check(h.pkg.PkgPath, h.pkg.Fset, files, nil)
+	return af, errs, nil
+}
+
+// dropFields removes the fields of the filled literal that contain one
+// of errs. It reports false if any error could not be attributed to a field.
+func (h *handler) dropFields(af *ast.File, errs []types.Error) bool {
+	result := locate(h.pkg.Fset, af, h.resultNode)
+	if result == nil {
+		return false
+	}
+	var dropped []ast.Node
+next:
+	for _, e := range errs {
+		if !e.Pos.IsValid() || e.Pos < result.Pos() || e.Pos >= result.End() {
+			return false
+		}
+		for _, n := range dropped {
+			if n.Pos() <= e.Pos && e.Pos < n.End() {
+				continue next
+			}
+		}
+		path, _ := astutil.PathEnclosingInterval(af, e.Pos, e.Pos)
+		removed := false
+		for i := 0; i+1 < len(path) && !removed; i++ {
+			kv, ok := path[i].(*ast.KeyValueExpr)
+			if !ok {
+				continue
+			}
+			lit, ok := path[i+1].(*ast.CompositeLit)
+			if !ok {
+				continue
+			}
+			for j, elt := range lit.Elts {
+				if elt != kv {
+					continue
+				}
+				prevEnd, next := lit.Lbrace, lit.Rbrace
+				if j > 0 {
+					prevEnd = lit.Elts[j-1].End()
+				}
+				if j+1 < len(lit.Elts) {
+					next = lit.Elts[j+1].Pos()
+				}
+				h.dropComments(af, kv, next)
+				h.removeLines(prevEnd, kv, next)
+				lit.Elts = append(lit.Elts[:j], lit.Elts[j+1:]...)
+				dropped = append(dropped, kv)
+				removed = true
+				break
+			}
+		}
+		if !removed {
+			h.merges = nil
+			return false
+		}
+	}
+	h.mergeLines(af)
+	return true
+}
+
+// newErrors returns the errors of errs not accounted for by known
+func newErrors(errs []types.Error, known map[string]int) (result []types.Error) {
+	seen := make(map[string]int)
+	for _, e := range errs {
+		if seen[e.Msg]++; seen[e.Msg] <= known[e.Msg] {
+			continue
+		}
+		result = append(result, e)
+	}
+	return
+}
+
+// locate finds the node of af that corresponds to node, a node of the
+// file before it was printed. Everything before a filled literal is left
+// untouched, so the node starts at the same line and column.
+func locate(fset *token.FileSet, af *ast.File, node ast.Node) (result ast.Node) {
+	if node == nil {
+		return
+	}
+	want := fset.Position(node.Pos())
+	ast.Inspect(af, func(n ast.Node) bool {
+		if result != nil || n == nil {
+			return false
+		}
+		pos := fset.Position(n.Pos())
+		if pos.Line == want.Line && pos.Column == want.Column && reflect.TypeOf(n) == reflect.TypeOf(node) {
+			result = n
+			return false
+		}
+		return true
+	})
+	return
+}